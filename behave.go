@@ -0,0 +1,307 @@
+package semver
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	goast "go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/quartercastle/semver/internal/ast"
+)
+
+// event is a line of the output of go test -json.
+type event struct {
+	Action     string
+	Package    string
+	ImportPath string
+	Test       string
+	Output     string
+}
+
+// Behave runs the test suite of the previous version against the latest
+// version, by copying the test files of the previous version into a
+// temporary copy of the latest version. Tests which fail, or don't even
+// compile, are reported as major changes, as the behaviour they verified
+// has changed. The failures are classified by the options like the changes
+// of CompareDirs, which options only configuring the comparison of the api,
+// like Engine, KeepGoing and Jobs, don't affect.
+func Behave(ctx context.Context, previous, latest string, opts Options) (*Report, error) {
+	if module(latest) == "" {
+		return nil, fmt.Errorf("latest version is not the root of a module: %s", latest)
+	}
+
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+
+	dir, err := os.MkdirTemp("", "semver-behave")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	if err := copyTree(latest, dir, func(path string, d fs.DirEntry) bool {
+		return !isTestFile(path, d)
+	}); err != nil {
+		return nil, err
+	}
+
+	if err := copyTree(previous, dir, func(path string, d fs.DirEntry) bool {
+		return isTestFile(path, d)
+	}); err != nil {
+		return nil, err
+	}
+
+	tests, err := testDeclarations(previous)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := exec.CommandContext(ctx, "go", "test", "-json", "./...")
+	cmd.Dir = dir
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	stderr := new(bytes.Buffer)
+	cmd.Stderr = stderr
+
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	root := importPath(latest)
+	diff, err := failures(stdout, root, tests)
+	// go test exits with an error when tests fail, which is what we are
+	// looking for, so only a missing result is a failure to run the tests
+	if werr := cmd.Wait(); werr != nil && len(diff) == 0 {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("go test: %w: %s", werr, strings.TrimSpace(stderr.String()))
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	var result ast.Diff
+	for _, change := range diff {
+		rel := strings.TrimPrefix(strings.TrimPrefix(change.Package, root), "/")
+		if opts.ignored(rel) {
+			continue
+		}
+
+		d := ast.Diff{change}
+		if !opts.IncludeInternal && (internal(change.Package) || mainPackage(filepath.Join(latest, rel))) {
+			d = downgrade(d)
+		}
+		result = result.Merge(d)
+	}
+
+	return opts.report(result), nil
+}
+
+// mainPackage reports whether the package in dir is a main package.
+func mainPackage(dir string) bool {
+	pkgs, err := parser.ParseDir(token.NewFileSet(), dir, func(f fs.FileInfo) bool {
+		return !strings.HasSuffix(f.Name(), "_test.go")
+	}, parser.PackageClauseOnly)
+
+	if err != nil {
+		return false
+	}
+
+	_, ok := pkgs["main"]
+	return ok
+}
+
+// Reasons of the failures of Behave.
+const (
+	failingTest  = "test of previous version fails against latest version"
+	failingTests = "tests of previous version fail to run against latest version"
+)
+
+// failures collects the failing tests and packages from the output of
+// go test -json.
+func failures(r io.Reader, root string, tests map[string]map[string]declaration) (ast.Diff, error) {
+	var diff ast.Diff
+	output := map[[2]string]*strings.Builder{}
+	failed := map[string]bool{}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	for scanner.Scan() {
+		var e event
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			// build errors are printed as plain text by older versions of go
+			continue
+		}
+
+		if e.Package == "" {
+			// build output is keyed by the import path of the test binary
+			e.Package = strings.SplitN(e.ImportPath, " ", 2)[0]
+		}
+
+		key := [2]string{e.Package, e.Test}
+		if output[key] == nil {
+			output[key] = new(strings.Builder)
+		}
+
+		switch e.Action {
+		case "output", "build-output":
+			output[key].WriteString(e.Output)
+		case "fail":
+			if e.Test == "" && failed[e.Package] {
+				// the package fails because of its failing tests
+				continue
+			}
+			failed[e.Package] = true
+
+			change := ast.Change{
+				Type:    ast.Major,
+				Reason:  failingTest,
+				Symbol:  e.Test,
+				Package: e.Package,
+				Output:  output[key].String(),
+			}
+
+			if e.Test == "" {
+				change.Reason = failingTests
+			}
+
+			// subtests are declared by their top level test
+			name := strings.SplitN(e.Test, "/", 2)[0]
+			rel := strings.TrimPrefix(strings.TrimPrefix(e.Package, root), "/")
+			if decl, ok := tests[rel][name]; ok {
+				change.PreviousPosition = decl.position
+				change.Previous = decl.node
+			}
+
+			diff = diff.Add(change)
+		}
+	}
+
+	return diff, scanner.Err()
+}
+
+type declaration struct {
+	node     ast.Node
+	position token.Position
+}
+
+// testDeclarations returns the test functions of every package in dir,
+// keyed by the slash separated path of the package relative to dir.
+func testDeclarations(dir string) (map[string]map[string]declaration, error) {
+	result := map[string]map[string]declaration{}
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !isTestFile(path, d) {
+			return err
+		}
+
+		fset := token.NewFileSet()
+		file, err := parser.ParseFile(fset, path, nil, 0)
+		if err != nil {
+			// broken tests are reported when they fail to compile
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, filepath.Dir(path))
+		if err != nil {
+			return err
+		}
+
+		pkg := filepath.ToSlash(rel)
+		if pkg == "." {
+			pkg = ""
+		}
+
+		if result[pkg] == nil {
+			result[pkg] = map[string]declaration{}
+		}
+
+		for _, decl := range file.Decls {
+			if f, ok := decl.(*goast.FuncDecl); ok && f.Recv == nil {
+				c := *f
+				c.Body = nil
+				result[pkg][f.Name.Name] = declaration{&c, fset.Position(f.Pos())}
+			}
+		}
+
+		return nil
+	})
+
+	return result, err
+}
+
+func isTestFile(path string, d fs.DirEntry) bool {
+	if d.IsDir() {
+		return false
+	}
+
+	// fixtures used by tests belong to the tests
+	for _, element := range strings.Split(filepath.ToSlash(path), "/") {
+		if element == "testdata" {
+			return true
+		}
+	}
+
+	return strings.HasSuffix(path, "_test.go")
+}
+
+// copyTree copies the files of src accepted by include into dst, leaving
+// out version control directories.
+func copyTree(src, dst string, include func(path string, d fs.DirEntry) bool) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() && d.Name() == ".git" {
+			return filepath.SkipDir
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() || !include(rel, d) {
+			return nil
+		}
+
+		target := filepath.Join(dst, rel)
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+
+		if d.Type()&fs.ModeSymlink != 0 {
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		return os.WriteFile(target, data, 0644)
+	})
+}
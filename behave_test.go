@@ -0,0 +1,127 @@
+package semver
+
+import (
+	"context"
+	"testing"
+)
+
+func TestBehave(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs go test")
+	}
+
+	previous := tree(t, map[string]string{
+		"go.mod":          "module example.com/foo\n",
+		"foo.go":          "package foo\nfunc Add(a, b int) int { return a + b }\n",
+		"foo_test.go":     "package foo\nimport \"testing\"\nfunc TestAdd(t *testing.T) {\n\tif Add(1, 2) != 3 {\n\t\tt.Error(\"expected 3\")\n\t}\n}\n",
+		"bar/bar.go":      "package bar\nfunc Bar() int { return 1 }\n",
+		"bar/bar_test.go": "package bar\nimport \"testing\"\nfunc TestBar(t *testing.T) {\n\tBar()\n}\n",
+	})
+
+	tc := []struct {
+		title    string
+		latest   map[string]string
+		expected Type
+		symbol   string
+	}{
+		{
+			"unchanged behaviour",
+			map[string]string{
+				"go.mod":      "module example.com/foo\n",
+				"foo.go":      "package foo\nfunc Add(a, b int) int { return b + a }\n",
+				"foo_test.go": "package foo\nimport \"testing\"\nfunc TestNew(t *testing.T) {\n\tt.Fail()\n}\n",
+				"bar/bar.go":  "package bar\nfunc Bar() int { return 2 }\n",
+			},
+			Patch,
+			"",
+		},
+		{
+			"changed behaviour",
+			map[string]string{
+				"go.mod":     "module example.com/foo\n",
+				"foo.go":     "package foo\nfunc Add(a, b int) int { return a - b }\n",
+				"bar/bar.go": "package bar\nfunc Bar() int { return 2 }\n",
+			},
+			Major,
+			"TestAdd",
+		},
+		{
+			"tests no longer compile",
+			map[string]string{
+				"go.mod":     "module example.com/foo\n",
+				"foo.go":     "package foo\nfunc Add(a, b int) int { return a + b }\n",
+				"bar/bar.go": "package bar\nfunc Bar(int) int { return 2 }\n",
+			},
+			Major,
+			"",
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.title, func(t *testing.T) {
+			report, err := Behave(context.Background(), previous, tree(t, c.latest), Options{})
+			if err != nil {
+				t.Fatalf("expected no error; got %s", err)
+			}
+
+			if report.Type != c.expected {
+				t.Fatalf("expected difference of %s; got %s: %+v", c.expected, report.Type, report.Changes)
+			}
+
+			if c.expected == Major && (len(report.Changes) != 1 || report.Changes[0].Symbol != c.symbol) {
+				t.Errorf("expected a single failure of %q; got %+v", c.symbol, report.Changes)
+			}
+		})
+	}
+}
+
+func TestBehaveOptions(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs go test")
+	}
+
+	previous := tree(t, map[string]string{
+		"go.mod":                   "module example.com/foo\n",
+		"internal/bar/bar.go":      "package bar\nfunc Bar() int { return 1 }\n",
+		"internal/bar/bar_test.go": "package bar\nimport \"testing\"\nfunc TestBar(t *testing.T) {\n\tif Bar() != 1 {\n\t\tt.Error(\"expected 1\")\n\t}\n}\n",
+	})
+
+	latest := tree(t, map[string]string{
+		"go.mod":              "module example.com/foo\n",
+		"internal/bar/bar.go": "package bar\nfunc Bar() int { return 2 }\n",
+	})
+
+	tc := []struct {
+		title    string
+		opts     Options
+		expected Type
+		changes  int
+	}{
+		{"internal package", Options{}, Patch, 1},
+		{"included internal package", Options{IncludeInternal: true}, Major, 1},
+		{
+			"suppressed failure",
+			Options{
+				IncludeInternal: true,
+				Suppressions:    []Suppression{{Package: "example.com/foo/internal/bar", Reason: "fixed a bug", Allow: Major}},
+			},
+			Patch,
+			1,
+		},
+		{"filtered failure", Options{IncludeInternal: true, Filter: []Type{Minor}}, Major, 0},
+		{"ignored package", Options{IncludeInternal: true, Ignore: []string{"internal"}}, Patch, 0},
+	}
+
+	for _, c := range tc {
+		t.Run(c.title, func(t *testing.T) {
+			report, err := Behave(context.Background(), previous, latest, c.opts)
+			if err != nil {
+				t.Fatalf("expected no error; got %s", err)
+			}
+
+			if report.Type != c.expected || len(report.Changes) != c.changes {
+				t.Errorf("expected difference of %s with %d changes; got %s: %+v", c.expected, c.changes, report.Type, report.Changes)
+			}
+		})
+	}
+}
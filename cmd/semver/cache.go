@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/quartercastle/semver/internal/git"
+)
+
+var units = []struct {
+	suffix string
+	size   int64
+}{
+	{"GB", 1 << 30},
+	{"MB", 1 << 20},
+	{"KB", 1 << 10},
+	{"B", 1},
+}
+
+// parseSize parses sizes like 500MB or 2GB, a plain number is in bytes.
+func parseSize(s string) (int64, error) {
+	if s == "" {
+		return 0, nil
+	}
+
+	s = strings.ToUpper(strings.TrimSpace(s))
+	for _, unit := range units {
+		if strings.HasSuffix(s, unit.suffix) {
+			n, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(s, unit.suffix)), 64)
+			if err != nil || n < 0 {
+				return 0, fmt.Errorf("invalid size: %s", s)
+			}
+			return int64(n * float64(unit.size)), nil
+		}
+	}
+
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size: %s", s)
+	}
+	return n, nil
+}
+
+func formatSize(size int64) string {
+	for _, unit := range units[:len(units)-1] {
+		if size >= unit.size {
+			return fmt.Sprintf("%.1f%s", float64(size)/float64(unit.size), unit.suffix)
+		}
+	}
+	return fmt.Sprintf("%dB", size)
+}
+
+// prune evicts references from the cache exceeding --max-size and --max-age.
+func prune() error {
+	size, err := parseSize(maxSize)
+	if err != nil {
+		return err
+	}
+
+	return store.Clean(size, maxAge)
+}
+
+// cacheCommand manages the cache of checked out references.
+func cacheCommand(args []string) error {
+	if len(args) == 0 || len(args) > 2 || len(args) == 2 && args[0] != "clear" {
+		return fmt.Errorf("invalid arguments: expected list, prune or clear [repository]")
+	}
+
+	if err := setup(); err != nil {
+		return err
+	}
+	defer teardown()
+
+	switch args[0] {
+	case "list":
+		entries, err := store.List()
+		if err != nil {
+			return err
+		}
+
+		var total int64
+		for _, e := range entries {
+			total += e.Size
+			fmt.Printf("%s\t%s\t%s\t%s\n", e.Path, e.Reference, formatSize(e.Size), e.Used.Format(time.RFC3339))
+		}
+		fmt.Printf("%d references, %s\n", len(entries), formatSize(total))
+		return nil
+	case "prune":
+		if maxSize == "" && maxAge == 0 {
+			return fmt.Errorf("prune requires --max-size or --max-age")
+		}
+		return prune()
+	case "clear":
+		if len(args) == 1 {
+			return store.Clear()
+		}
+
+		// repositories are cached by their root, but repositories which
+		// no longer exist can still be cleared by the path listed
+		repo := args[1]
+		if root, err := git.Root(repo); err == nil {
+			repo = root
+		}
+		return store.Remove(repo)
+	}
+
+	return fmt.Errorf("unknown cache command: %s", args[0])
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/quartercastle/semver"
+)
+
+// configFile is looked up at the root of the module in the working
+// directory.
+const configFile = ".semver.json"
+
+// config is the project configuration shared by everyone working on it,
+// flags given on the command line take precedence over it.
+type config struct {
+	// Ignore lists directories relative to the module root which are left
+	// out of the comparison.
+	Ignore          []string `json:"ignore"`
+	Format          string   `json:"format"`
+	Engine          string   `json:"engine"`
+	IncludeInternal *bool    `json:"include-internal"`
+	FailOn          string   `json:"fail-on"`
+	// V0 decides how breaking changes bump a v0 module, either minor or
+	// major.
+	V0 string `json:"v0"`
+	// Rules override the type of changes by the reason they are explained
+	// with.
+	Rules        map[string]semver.Type `json:"rules"`
+	Suppressions []semver.Suppression   `json:"suppressions"`
+}
+
+// apply copies the settings of the configuration into the flags which
+// weren't given on the command line.
+func (c config) apply() {
+	given := map[string]bool{}
+	flag.Visit(func(f *flag.Flag) {
+		given[f.Name] = true
+	})
+
+	if !given["ignore"] && len(c.Ignore) > 0 {
+		ignore = strings.Join(c.Ignore, ",")
+	}
+	if !given["format"] && c.Format != "" {
+		format = c.Format
+	}
+	if !given["engine"] && c.Engine != "" {
+		engine = c.Engine
+	}
+	if !given["include-internal"] && c.IncludeInternal != nil {
+		internal = *c.IncludeInternal
+	}
+	if !given["fail-on"] && c.FailOn != "" {
+		failOn = c.FailOn
+	}
+	if !given["v0"] && c.V0 != "" {
+		v0 = c.V0
+	}
+}
+
+// moduleRoot returns the closest directory from the working directory
+// containing a go.mod.
+func moduleRoot() string {
+	dir, err := os.Getwd()
+	if err != nil {
+		return ""
+	}
+
+	for {
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+			return dir
+		}
+
+		if filepath.Dir(dir) == dir {
+			return ""
+		}
+		dir = filepath.Dir(dir)
+	}
+}
+
+// loadConfig reads the configuration of the project, which is empty if it
+// has none.
+func loadConfig() (config, error) {
+	var c config
+
+	root := moduleRoot()
+	if root == "" {
+		return c, nil
+	}
+
+	path := filepath.Join(root, configFile)
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return c, nil
+	}
+
+	if err != nil {
+		return c, err
+	}
+
+	if err := json.Unmarshal(data, &c); err != nil {
+		return c, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return c, nil
+}
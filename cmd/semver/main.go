@@ -1,190 +1,394 @@
 package main
 
 import (
-	"bytes"
+	"context"
+	"errors"
 	"flag"
 	"fmt"
-	"go/parser"
-	"go/printer"
-	"go/token"
-	"io/fs"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
 	"time"
 
-	"github.com/quartercastle/semver/internal/ast"
+	"github.com/quartercastle/semver"
+	"github.com/quartercastle/semver/internal/cache"
+	"github.com/quartercastle/semver/internal/git"
 )
 
-func merge[T any](a, b map[string]T) map[string]struct{} {
-	result := map[string]struct{}{}
-	for k := range a {
-		result[k] = struct{}{}
-	}
-	for k := range b {
-		result[k] = struct{}{}
-	}
-	return result
-}
-
 var (
-	filter  string
-	grep    string
-	explain bool
+	filter    string
+	grep      string
+	format    string
+	engine    string
+	explain   bool
+	keepGoing bool
+	internal  bool
+	maxSize   string
+	maxAge    time.Duration
+	jobs      int
+	ignore    string
+	v0        string
+	failOn    string
+
+	// project is the configuration of the module in the working directory.
+	project config
+
+	// store is the cache of checked out references, opened by setup.
+	store *cache.Cache
 )
 
 func init() {
 	flag.BoolVar(&explain, "explain", false, "explain reason behind decision")
+	flag.BoolVar(&keepGoing, "keep-going", false, "report unparseable packages as unknown instead of failing")
+	flag.BoolVar(&internal, "include-internal", false, "classify changes to internal and main packages as api changes")
+	flag.StringVar(&format, "format", "text", "output format: text, json")
+	flag.StringVar(&engine, "engine", "ast", "comparison engine: ast, types")
 	flag.StringVar(&filter, "filter", "", "filter between changes: patch, minor, major")
 	flag.StringVar(&grep, "grep", "", "grep output")
+	flag.StringVar(&ignore, "ignore", "", "comma separated directories to leave out of the comparison")
+	flag.StringVar(&failOn, "fail-on", "", "exit with the code of the verdict when it is at least: minor, major")
+	flag.StringVar(&v0, "v0", "minor", "bump of breaking changes to a v0 module: minor, major")
+	flag.StringVar(&maxSize, "max-size", "", "evict least recently used references when the cache exceeds this size, like 500MB")
+	flag.IntVar(&jobs, "j", runtime.NumCPU(), "number of packages compared in parallel")
+	flag.DurationVar(&maxAge, "max-age", 0, "evict references which haven't been used within this duration")
 }
 
-func walk(origin, target string) (ast.Diff, error) {
-	ignore := map[string]struct{}{
-		".git":    {},
-		".github": {},
+func options() (semver.Options, error) {
+	opts := semver.Options{
+		Engine:          semver.Engine(engine),
+		KeepGoing:       keepGoing,
+		Grep:            grep,
+		IncludeInternal: internal,
+		Jobs:            jobs,
+		Severities:      project.Rules,
+		Suppressions:    project.Suppressions,
 	}
 
-	previous := map[string]struct{}{}
-	a, err := os.ReadDir(origin)
-
-	if err != nil && !os.IsNotExist(err) {
-		return nil, err
+	if ignore != "" {
+		opts.Ignore = strings.Split(ignore, ",")
 	}
 
-	for _, entry := range a {
-		if !entry.IsDir() {
-			continue
+	if filter != "" {
+		t, err := semver.ParseType(filter)
+		if err != nil {
+			return opts, err
 		}
+		opts.Filter = []semver.Type{t}
+	}
 
-		previous[entry.Name()] = struct{}{}
+	return opts, nil
+}
+
+// resolve returns the directory to compare for arg. Directories are used
+// as is, anything else is treated as a git reference in the current
+// repository and checked out into the cache.
+func resolve(arg string) (string, error) {
+	if info, err := os.Stat(arg); err == nil && info.IsDir() {
+		return arg, nil
+	}
+
+	root, err := git.Root(".")
+	if err != nil {
+		return "", fmt.Errorf("%s is neither a directory nor a git reference: %w", arg, err)
 	}
 
-	latest := map[string]struct{}{}
-	b, err := os.ReadDir(target)
+	commit, err := git.Resolve(root, arg)
+	if err != nil {
+		return "", err
+	}
+
+	if path, err := store.Get(root, commit); err == nil {
+		return path, nil
+	}
 
-	if err != nil && !os.IsNotExist(err) {
+	staged, err := store.Add(root, commit)
+	if err != nil {
+		return "", err
+	}
+
+	if err := git.Extract(root, commit, staged); err != nil {
+		return "", err
+	}
+
+	return store.Save(root, commit, staged)
+}
+
+// relabel replaces the cache location of files checked out from a git
+// reference with the reference itself, which is what the user asked for and
+// stays meaningful when the checkout is evicted from the cache.
+func relabel(filename, dir, reference string) string {
+	if dir == reference || filename == "" {
+		return filename
+	}
+
+	rel, err := filepath.Rel(dir, filename)
+	if err != nil {
+		return filename
+	}
+
+	return reference + ":" + filepath.ToSlash(rel)
+}
+
+// compare compares two resolved versions, labelling their files with the
+// arguments they were resolved from.
+func compare(origin, target, previous, latest string) (*semver.Report, error) {
+	opts, err := options()
+	if err != nil {
 		return nil, err
 	}
 
-	for _, entry := range b {
-		if !entry.IsDir() {
-			continue
+	report, err := semver.CompareDirs(context.Background(), origin, target, opts)
+
+	var perr *semver.ParseError
+	if errors.As(err, &perr) {
+		if perr.Version == "previous" {
+			perr.Position.Filename = relabel(perr.Position.Filename, origin, previous)
+		} else {
+			perr.Position.Filename = relabel(perr.Position.Filename, target, latest)
 		}
+	}
 
-		latest[entry.Name()] = struct{}{}
+	var ierr *semver.ImportError
+	if errors.As(err, &ierr) {
+		if ierr.Version == "previous" {
+			ierr.Position.Filename = relabel(ierr.Position.Filename, origin, previous)
+		} else {
+			ierr.Position.Filename = relabel(ierr.Position.Filename, target, latest)
+		}
 	}
 
-	packages := merge(previous, latest)
+	if err != nil {
+		return nil, err
+	}
 
-	var diff ast.Diff
-	for pkg := range packages {
-		if _, ok := ignore[pkg]; ok {
-			continue
+	for _, change := range report.Changes {
+		if change.Previous != nil {
+			change.Previous.File = relabel(change.Previous.File, origin, previous)
+		}
+		if change.Latest != nil {
+			change.Latest.File = relabel(change.Latest.File, target, latest)
 		}
+	}
 
-		d, err := walk(
-			filepath.Join(origin, pkg),
-			filepath.Join(target, pkg),
-		)
+	return report, nil
+}
 
-		diff = diff.Merge(d)
+// behave runs the tests of the previous version against the latest version.
+func behave(args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("invalid arguments")
+	}
 
-		if err != nil {
-			return diff, err
+	if err := setup(); err != nil {
+		return err
+	}
+	defer teardown()
+
+	origin, err := resolve(args[0])
+	if err != nil {
+		return err
+	}
+
+	target, err := resolve(args[1])
+	if err != nil {
+		return err
+	}
+
+	opts, err := options()
+	if err != nil {
+		return err
+	}
+
+	start := time.Now()
+	report, err := semver.Behave(context.Background(), origin, target, opts)
+	if err != nil {
+		return err
+	}
+
+	for _, change := range report.Changes {
+		if change.Previous != nil {
+			change.Previous.File = relabel(change.Previous.File, origin, args[0])
 		}
 	}
 
-	d, err := compare(origin, target)
-	return diff.Merge(d), err
+	if err := output(report, time.Since(start)); err != nil {
+		return err
+	}
+
+	return gate(report.Type)
 }
 
-func compare(origin, target string) (ast.Diff, error) {
-	a := token.NewFileSet()
-	previous, err := parser.ParseDir(a, origin, func(f fs.FileInfo) bool {
-		return !strings.Contains(f.Name(), "_test.go")
-	}, 0)
+// exitCodes are the codes the process exits with when the verdict fails
+// --fail-on, 1 is used for errors and 2 for invalid flags, like the flag
+// package does.
+var exitCodes = map[semver.Type]int{
+	semver.Minor:   3,
+	semver.Major:   4,
+	semver.Unknown: 5,
+}
 
-	if err != nil && !os.IsNotExist(err) {
-		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
-	}
+// failure is returned when the verdict fails --fail-on.
+type failure semver.Type
 
-	b := token.NewFileSet()
-	latest, err := parser.ParseDir(b, target, func(f fs.FileInfo) bool {
-		return !strings.Contains(f.Name(), "_test.go")
-	}, 0)
+func (f failure) Error() string {
+	return fmt.Sprintf("%s change fails --fail-on %s", semver.Type(f), failOn)
+}
 
-	if err != nil && !os.IsNotExist(err) {
-		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+// gate fails if the verdict is at least the type of change given by
+// --fail-on, which has been validated.
+func gate(verdict semver.Type) error {
+	if failOn == "" {
+		return nil
 	}
 
-	all := merge(latest, previous)
+	if t, _ := semver.ParseType(failOn); verdict >= t {
+		return failure(verdict)
+	}
+
+	return nil
+}
 
-	var diff ast.Diff
-	for pkg := range all {
-		diff = diff.Merge(ast.Compare(previous[pkg], latest[pkg]))
+// validate validates the values of the flags before doing any work,
+// whether they are given on the command line or in the configuration.
+func validate() error {
+	if format != "text" && format != "json" {
+		return fmt.Errorf("--format must be either text or json, got %s", format)
 	}
 
-	if !explain {
-		return diff, nil
+	if engine != string(semver.AST) && engine != string(semver.Types) {
+		return fmt.Errorf("--engine must be either ast or types, got %s", engine)
 	}
 
-	for _, change := range diff {
-		if filter != "" {
-			if change.Type.String() != strings.ToUpper(filter) {
-				continue
-			}
+	if filter != "" {
+		if _, err := semver.ParseType(filter); err != nil {
+			return fmt.Errorf("--filter must be either patch, minor, major or unknown, got %s", filter)
 		}
+	}
 
-		if grep != "" {
-			buffer := new(bytes.Buffer)
-			printer.Fprint(buffer, token.NewFileSet(), change.Previous)
-			printer.Fprint(buffer, token.NewFileSet(), change.Latest)
-			if !strings.Contains(buffer.String(), grep) {
-				continue
-			}
+	if failOn != "" {
+		if t, err := semver.ParseType(failOn); err != nil || (t != semver.Minor && t != semver.Major) {
+			return fmt.Errorf("--fail-on must be either minor or major, got %s", failOn)
 		}
+	}
 
-		fmt.Printf("%s: %s\n", change.Type, change.Reason)
+	if v0 != "minor" && v0 != "major" {
+		return fmt.Errorf("--v0 must be either minor or major, got %s", v0)
+	}
 
-		if change.Previous != nil {
-			fmt.Println(a.Position(change.Previous.Pos()))
-			fmt.Print("- ")
-		}
-		printer.Fprint(os.Stdout, token.NewFileSet(), change.Previous)
-		if change.Previous != nil {
-			fmt.Println()
-		}
-		if change.Latest != nil {
-			fmt.Println(b.Position(change.Latest.Pos()))
-			fmt.Print("+ ")
-		}
-		printer.Fprint(os.Stdout, token.NewFileSet(), change.Latest)
-		fmt.Println()
-		if change.Latest != nil {
-			fmt.Println()
-		}
+	if _, err := parseSize(maxSize); err != nil {
+		return fmt.Errorf("--max-size: %w", err)
 	}
 
-	return diff, nil
+	return nil
 }
 
-func main() {
-	flag.Parse()
-	args := flag.Args()
+// setup opens the cache of checked out references, which is kept in the
+// user cache directory between runs.
+func setup() error {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+
+	store, err = cache.Open(filepath.Join(dir, "semver"))
+	return err
+}
+
+func teardown() {
+	// the references of this run are done with, so they can be evicted
+	store.Release()
 
+	if maxSize != "" || maxAge != 0 {
+		if err := prune(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+	}
+	store.Close()
+}
+
+func run(args []string) error {
 	if len(args) != 2 {
-		fmt.Fprintln(os.Stderr, "invalid arguments")
-		os.Exit(1)
+		return fmt.Errorf("invalid arguments")
+	}
+
+	if err := setup(); err != nil {
+		return err
+	}
+	defer teardown()
+
+	origin, err := resolve(args[0])
+	if err != nil {
+		return err
+	}
+
+	target, err := resolve(args[1])
+	if err != nil {
+		return err
 	}
 
 	start := time.Now()
-	diff, err := walk(args[0], args[1])
+	report, err := compare(origin, target, args[0], args[1])
 	if err != nil {
-		fmt.Println(err)
+		return err
+	}
+
+	if err := output(report, time.Since(start)); err != nil {
+		return err
+	}
+
+	return gate(report.Type)
+}
+
+// parse parses flags placed anywhere between the arguments, so they can
+// follow the name of a command.
+func parse(args []string) []string {
+	positional := []string{}
+	for {
+		flag.CommandLine.Parse(args)
+		args = flag.Args()
+
+		if len(args) == 0 {
+			return positional
+		}
+
+		positional, args = append(positional, args[0]), args[1:]
+	}
+}
+
+func main() {
+	args := parse(os.Args[1:])
+
+	var err error
+	if project, err = loadConfig(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	project.apply()
+
+	if err := validate(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	commands := map[string]func([]string) error{
+		"next":   next,
+		"behave": behave,
+		"cache":  cacheCommand,
+	}
+
+	command := run
+	if len(args) > 0 && commands[args[0]] != nil {
+		command, args = commands[args[0]], args[1:]
+	}
+
+	if err := command(args); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+
+		var f failure
+		if errors.As(err, &f) {
+			os.Exit(exitCodes[semver.Type(f)])
+		}
 		os.Exit(1)
 	}
-	fmt.Println(diff.Type(), time.Since(start))
 }
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/quartercastle/semver"
+	"github.com/quartercastle/semver/internal/git"
+)
+
+// latest returns the highest release tag reachable from HEAD.
+func latest(root string) (string, semver.Version, error) {
+	tags, err := git.Tags(root)
+	if err != nil {
+		return "", semver.Version{}, err
+	}
+
+	var (
+		tag     string
+		highest semver.Version
+	)
+	for _, t := range tags {
+		v, err := semver.Parse(t)
+		if err != nil || !v.Prefix || len(v.Prerelease) > 0 || len(v.Build) > 0 {
+			continue
+		}
+
+		if tag == "" || highest.Less(v) {
+			tag, highest = t, v
+		}
+	}
+
+	if tag == "" {
+		return "", semver.Version{}, fmt.Errorf("no release tag reachable from HEAD")
+	}
+
+	return tag, highest, nil
+}
+
+// next compares the latest release tag with the working tree and prints
+// the version the working tree should be released as.
+func next(args []string) error {
+	if len(args) != 0 {
+		return fmt.Errorf("invalid arguments")
+	}
+
+	root, err := git.Root(".")
+	if err != nil {
+		return err
+	}
+
+	tag, current, err := latest(root)
+	if err != nil {
+		return err
+	}
+
+	if err := setup(); err != nil {
+		return err
+	}
+	defer teardown()
+
+	origin, err := resolve(tag)
+	if err != nil {
+		return err
+	}
+
+	report, err := compare(origin, root, tag, root)
+	if err != nil {
+		return err
+	}
+
+	if explain {
+		outputText(report, 0)
+	}
+
+	change := report.Type
+	if change == semver.Unknown {
+		return fmt.Errorf("next version is unknown, as some packages could not be parsed")
+	}
+
+	// v0 has no compatibility promise, so breaking changes only bump minor
+	// unless the project opts into releasing them as v1
+	switch v0 {
+	case "minor":
+		if change == semver.Major && current.Major == 0 {
+			change = semver.Minor
+		}
+	case "major":
+	default:
+		return fmt.Errorf("unknown v0 policy: %s", v0)
+	}
+
+	fmt.Println(current.Bump(change))
+	return nil
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/quartercastle/semver"
+)
+
+func output(report *semver.Report, elapsed time.Duration) error {
+	switch format {
+	case "json":
+		return outputJSON(report)
+	case "text":
+		outputText(report, elapsed)
+		return nil
+	}
+
+	return fmt.Errorf("unknown format: %s", format)
+}
+
+func outputJSON(report *semver.Report) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
+
+func position(d *semver.Declaration) string {
+	if d.Line == 0 {
+		return d.File
+	}
+	return fmt.Sprintf("%s:%d:%d", d.File, d.Line, d.Column)
+}
+
+func outputText(report *semver.Report, elapsed time.Duration) {
+	if explain {
+		for _, c := range report.Changes {
+			if c.Suppressed != "" {
+				fmt.Printf("%s (suppressed: %s): %s\n", c.Type, c.Suppressed, c.Reason)
+			} else if c.Internal {
+				fmt.Printf("%s (internal): %s\n", c.Type, c.Reason)
+			} else {
+				fmt.Printf("%s: %s\n", c.Type, c.Reason)
+			}
+
+			if c.Note != "" {
+				fmt.Printf("note: %s\n", c.Note)
+			}
+
+			if c.Previous != nil {
+				if c.Previous.File != "" {
+					fmt.Println(position(c.Previous))
+				}
+				if c.Previous.Source != "" {
+					fmt.Printf("- %s\n", c.Previous.Source)
+				}
+			}
+
+			if c.Latest != nil {
+				if c.Latest.File != "" {
+					fmt.Println(position(c.Latest))
+				}
+				if c.Latest.Source != "" {
+					fmt.Printf("+ %s\n", c.Latest.Source)
+				}
+			}
+
+			if c.Output != "" {
+				fmt.Print(c.Output)
+			}
+
+			fmt.Println()
+		}
+	}
+
+	for _, warning := range report.Warnings {
+		fmt.Fprintf(os.Stderr, "warning: %s\n", warning)
+	}
+
+	fmt.Println(report.Type, elapsed)
+}
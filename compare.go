@@ -0,0 +1,571 @@
+package semver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go/parser"
+	"go/token"
+	gotypes "go/types"
+	"io/fs"
+	"os"
+	pathpkg "path"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/quartercastle/semver/internal/ast"
+	"github.com/quartercastle/semver/internal/types"
+)
+
+// Engine decides how two versions of a package are compared.
+type Engine string
+
+const (
+	// AST compares the shape of the syntax trees, which is fast but can't
+	// tell types of the same name from different packages apart.
+	AST Engine = "ast"
+	// Types type checks both versions and compares the exported api by
+	// fully qualified types, resolving aliases along the way.
+	Types Engine = "types"
+)
+
+// Options configure a comparison and mirror the flags of the semver cli.
+type Options struct {
+	// Engine defaults to AST.
+	Engine Engine
+	// KeepGoing reports packages which can't be parsed, or whose imports
+	// can't be resolved by the Types engine, as an Unknown change instead of
+	// failing with a ParseError or ImportError.
+	KeepGoing bool
+	// Filter limits the changes in the report to the given types, all
+	// changes are reported if empty.
+	Filter []Type
+	// Grep limits the changes in the report to those whose source
+	// contains the string.
+	Grep string
+	// IncludeInternal classifies changes to internal and main packages,
+	// which can't be imported by other modules, like any other change.
+	// By default they are reported as patches.
+	IncludeInternal bool
+	// Jobs is the number of packages compared in parallel, it defaults to
+	// the number of CPUs.
+	Jobs int
+	// Suppressions acknowledge changes which are shipped knowingly, they
+	// are applied before the type of the report is decided.
+	Suppressions []Suppression
+	// Ignore lists slash separated directories, relative to the root of the
+	// versions, which are left out of the comparison along with their sub
+	// directories.
+	Ignore []string
+	// Severities override the type of changes by their reason, like
+	// "const has changed value". Internal changes are left as patches, and
+	// reasons no change is explained with are invalid.
+	Severities map[string]Type
+}
+
+func (o Options) selected(c Change) bool {
+	if len(o.Filter) > 0 {
+		found := false
+		for _, t := range o.Filter {
+			if c.Type == t {
+				found = true
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	if o.Grep != "" {
+		var source string
+		if c.Previous != nil {
+			source += c.Previous.Source
+		}
+		if c.Latest != nil {
+			source += c.Latest.Source
+		}
+
+		if !strings.Contains(source, o.Grep) {
+			return false
+		}
+	}
+
+	return true
+}
+
+type comparison struct {
+	ctx  context.Context
+	opts Options
+
+	// modules of each version, by their path, which are found while walking
+	// the trees
+	modules [2]map[string]string
+
+	// importers are shared by every package of a version, as they cache
+	// the packages they have type checked
+	importers [2]gotypes.Importer
+}
+
+// CompareDirs compares the exported api of every package in the directory
+// trees of two versions of a module.
+func CompareDirs(ctx context.Context, previous, latest string, opts Options) (*Report, error) {
+	if opts.Engine == "" {
+		opts.Engine = AST
+	}
+
+	if opts.Engine != AST && opts.Engine != Types {
+		return nil, fmt.Errorf("%w: %s", UnknownEngine, opts.Engine)
+	}
+
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+
+	if opts.Jobs <= 0 {
+		opts.Jobs = runtime.GOMAXPROCS(0)
+	}
+
+	c := &comparison{ctx: ctx, opts: opts, modules: [2]map[string]string{{}, {}}}
+	for i, dir := range []string{previous, latest} {
+		if path, root := moduleRoot(dir); path != "" {
+			c.modules[i][path] = root
+		}
+	}
+
+	path := importPath(latest)
+	if path == "" {
+		path = importPath(previous)
+	}
+
+	dirs, err := c.walk(previous, latest, path, "", path != "", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Engine == Types {
+		c.importers = [2]gotypes.Importer{
+			types.NewImporter(c.modules[0]),
+			types.NewImporter(c.modules[1]),
+		}
+	}
+
+	diff, err := c.compareAll(dirs)
+	if err != nil {
+		return nil, err
+	}
+
+	return opts.report(diff), nil
+}
+
+// validate validates the options shared by CompareDirs and Behave.
+func (o Options) validate() error {
+	for _, s := range o.Suppressions {
+		if err := s.validate(); err != nil {
+			return err
+		}
+	}
+
+	reasons := []string{}
+	for reason := range o.Severities {
+		reasons = append(reasons, reason)
+	}
+	sort.Strings(reasons)
+
+	// reasons differ between the engines, a rule is valid if either of
+	// them explains changes with it
+	for _, reason := range reasons {
+		if !ast.KnownReason(reason) && reason != failingTest && reason != failingTests {
+			return fmt.Errorf("%w: no change is explained with %q", InvalidRule, reason)
+		}
+	}
+
+	return nil
+}
+
+// report applies the severities and suppressions to the changes, which
+// decide the type of the report, before selecting the changes to report.
+func (o Options) report(diff ast.Diff) *Report {
+	for i, change := range diff {
+		if t, ok := o.Severities[change.Reason]; ok && !change.Internal {
+			diff[i].Type = t
+		}
+	}
+
+	warnings := suppress(diff, o.Suppressions)
+
+	report := &Report{Type: diff.Type(), Changes: []Change{}, Warnings: warnings}
+	for _, d := range diff {
+		if change := newChange(d); o.selected(change) {
+			report.Changes = append(report.Changes, change)
+		}
+	}
+	report.sort()
+
+	return report
+}
+
+func merge[T any](a, b map[string]T) map[string]struct{} {
+	result := map[string]struct{}{}
+	for k := range a {
+		result[k] = struct{}{}
+	}
+	for k := range b {
+		result[k] = struct{}{}
+	}
+	return result
+}
+
+// dir is a directory of both versions, which can contain a package.
+type dir struct {
+	origin, target, path string
+}
+
+// walk collects the directories of both trees in a stable order, parents
+// before their sub directories. Rel is the slash separated path of the
+// directories relative to the root of the trees. Within a module, nested
+// modules are left out, otherwise every module found is walked.
+func (c *comparison) walk(origin, target, path, rel string, module bool, dirs []dir) ([]dir, error) {
+	if err := c.ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if c.opts.ignored(rel) {
+		return dirs, nil
+	}
+
+	previous, err := packages(origin, module)
+	if err != nil {
+		return nil, err
+	}
+
+	latest, err := packages(target, module)
+	if err != nil {
+		return nil, err
+	}
+
+	dirs = append(dirs, dir{origin, target, path})
+
+	names := []string{}
+	for pkg := range merge(previous, latest) {
+		names = append(names, pkg)
+	}
+	sort.Strings(names)
+
+	for _, pkg := range names {
+		sub := dir{filepath.Join(origin, pkg), filepath.Join(target, pkg), join(path, pkg)}
+
+		inside := module
+		if !inside {
+			if m := c.nested(sub.origin, sub.target); m != "" {
+				sub.path, inside = m, true
+			}
+		}
+
+		dirs, err = c.walk(sub.origin, sub.target, sub.path, join(rel, pkg), inside, dirs)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return dirs, nil
+}
+
+// ignored reports whether the directory is within one of the ignored
+// directories.
+func (o Options) ignored(rel string) bool {
+	for _, ignore := range o.Ignore {
+		ignore = strings.TrimSuffix(pathpkg.Clean(ignore), "/...")
+		if rel == ignore || strings.HasPrefix(rel, ignore+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// compareAll compares the packages of the directories with a pool of
+// workers. The diffs are merged in the order of the directories, and the
+// error of the first directory failing is returned.
+func (c *comparison) compareAll(dirs []dir) (ast.Diff, error) {
+	diffs := make([]ast.Diff, len(dirs))
+	errs := make([]error, len(dirs))
+
+	// directories after the first one failing are skipped
+	var mu sync.Mutex
+	failed := len(dirs)
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < c.opts.Jobs; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				mu.Lock()
+				skip := j > failed
+				mu.Unlock()
+
+				if skip {
+					continue
+				}
+
+				if err := c.ctx.Err(); err != nil {
+					errs[j] = err
+				} else {
+					diffs[j], errs[j] = c.compare(dirs[j].origin, dirs[j].target, dirs[j].path)
+				}
+
+				if errs[j] != nil {
+					mu.Lock()
+					if j < failed {
+						failed = j
+					}
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+
+	for i := range dirs {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	var diff ast.Diff
+	for i := range dirs {
+		diff = diff.Merge(diffs[i])
+		if errs[i] != nil {
+			return diff, errs[i]
+		}
+	}
+
+	return diff, nil
+}
+
+// nested registers the modules in the directories of both versions, and
+// returns the path of the module, which is empty if there is none.
+func (c *comparison) nested(origin, target string) string {
+	path := ""
+	for i, dir := range []string{origin, target} {
+		if m := module(dir); m != "" {
+			c.modules[i][m] = dir
+			path = m
+		}
+	}
+	return path
+}
+
+// packages returns the sub directories of dir which can contain packages.
+// Like the go tool, directories named vendor or testdata and prefixed with
+// . or _ are left out, as are nested modules within a module.
+func packages(dir string, module bool) (map[string]struct{}, error) {
+	result := map[string]struct{}{}
+	entries, err := os.ReadDir(dir)
+
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+
+		if !entry.IsDir() || name == "vendor" || name == "testdata" ||
+			strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") {
+			continue
+		}
+
+		if _, err := os.Stat(filepath.Join(dir, name, "go.mod")); err == nil && module {
+			continue
+		}
+
+		result[name] = struct{}{}
+	}
+
+	return result, nil
+}
+
+func join(path, pkg string) string {
+	if path == "" {
+		return pkg
+	}
+	return path + "/" + pkg
+}
+
+// moduleRoot returns the path and directory of the module dir belongs to,
+// by looking for its go.mod in dir and its parents.
+func moduleRoot(dir string) (string, string) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", ""
+	}
+
+	for current := abs; ; current = filepath.Dir(current) {
+		if path := module(current); path != "" {
+			return path, current
+		}
+
+		if filepath.Dir(current) == current {
+			return "", ""
+		}
+	}
+}
+
+// importPath returns the import path of the package in dir, based on the
+// module it belongs to.
+func importPath(dir string) string {
+	path, root := moduleRoot(dir)
+	if path == "" {
+		return ""
+	}
+
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return path
+	}
+
+	rel, err := filepath.Rel(root, abs)
+	if err != nil || rel == "." {
+		return path
+	}
+	return path + "/" + filepath.ToSlash(rel)
+}
+
+// module returns the module path declared in the go.mod of dir.
+func module(dir string) string {
+	data, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+	if err != nil {
+		return ""
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && fields[0] == "module" {
+			return strings.Trim(fields[1], `"`)
+		}
+	}
+
+	return ""
+}
+
+// unknown records a package which could not be parsed or type checked as
+// an unknown change when keeping going, otherwise the error is returned.
+func (c *comparison) unknown(err error) (ast.Diff, error) {
+	if !c.opts.KeepGoing {
+		return nil, err
+	}
+
+	change := ast.Change{Type: ast.Unknown}
+
+	var version string
+	var position token.Position
+	switch e := err.(type) {
+	case *ParseError:
+		change.Reason = fmt.Sprintf("could not parse %s version: %s", e.Version, e.Err)
+		change.Package, version, position = e.Package, e.Version, e.Position
+	case *ImportError:
+		change.Reason = fmt.Sprintf("could not import %s in %s version: %s", e.Import, e.Version, e.Err)
+		change.Package, version, position = e.Package, e.Version, e.Position
+	}
+
+	if version == "previous" {
+		change.PreviousPosition = position
+	} else {
+		change.LatestPosition = position
+	}
+
+	if !c.opts.IncludeInternal && internal(change.Package) {
+		return downgrade(ast.Diff{change}), nil
+	}
+
+	return ast.Diff{change}, nil
+}
+
+// internal reports whether the import path is within an internal directory,
+// which makes it impossible to import from other modules.
+func internal(path string) bool {
+	for _, element := range strings.Split(path, "/") {
+		if element == "internal" {
+			return true
+		}
+	}
+	return false
+}
+
+// downgrade marks changes to packages which aren't part of the api as
+// patches.
+func downgrade(diff ast.Diff) ast.Diff {
+	for i := range diff {
+		diff[i].Type = ast.Patch
+		diff[i].Internal = true
+	}
+	return diff
+}
+
+func (c *comparison) compare(origin, target, path string) (ast.Diff, error) {
+	a := token.NewFileSet()
+	previous, err := parser.ParseDir(a, origin, func(f fs.FileInfo) bool {
+		return !strings.Contains(f.Name(), "_test.go")
+	}, 0)
+
+	if err != nil && !os.IsNotExist(err) {
+		return c.unknown(newParseError("previous", path, origin, err))
+	}
+
+	b := token.NewFileSet()
+	latest, err := parser.ParseDir(b, target, func(f fs.FileInfo) bool {
+		return !strings.Contains(f.Name(), "_test.go")
+	}, 0)
+
+	if err != nil && !os.IsNotExist(err) {
+		return c.unknown(newParseError("latest", path, target, err))
+	}
+
+	all := merge(latest, previous)
+
+	var diff ast.Diff
+	for pkg := range all {
+		p, l := previous[pkg], latest[pkg]
+
+		var d ast.Diff
+		// additions and removals of packages are judged the same by both engines
+		if c.opts.Engine == Types && p != nil && l != nil {
+			pc, err := types.Check(path, a, p, c.importers[0])
+			var ierr *types.ImportError
+			if errors.As(err, &ierr) {
+				return c.unknown(newImportError("previous", path, a, ierr))
+			}
+
+			lc, err := types.Check(path, b, l, c.importers[1])
+			if errors.As(err, &ierr) {
+				return c.unknown(newImportError("latest", path, b, ierr))
+			}
+
+			d = types.Compare(pc, lc)
+		} else {
+			d = ast.Compare(p, l)
+		}
+
+		if !c.opts.IncludeInternal && (pkg == "main" || internal(path)) {
+			d = downgrade(d)
+		}
+
+		diff = diff.Merge(d)
+	}
+
+	for i, change := range diff {
+		diff[i].Package = path
+		if change.Previous != nil {
+			diff[i].PreviousPosition = a.Position(change.Previous.Pos())
+		}
+		if change.Latest != nil {
+			diff[i].LatestPosition = b.Position(change.Latest.Pos())
+		}
+	}
+
+	return diff, nil
+}
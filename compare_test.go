@@ -0,0 +1,536 @@
+package semver
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// tree writes files, keyed by their slash separated path, into a temporary
+// directory.
+func tree(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return dir
+}
+
+func TestCompareDirs(t *testing.T) {
+	previous := tree(t, map[string]string{
+		"go.mod":     "module example.com/foo\n",
+		"foo.go":     "package foo\nfunc Foo() {}\n",
+		"bar/bar.go": "package bar\nfunc Bar() {}\n",
+	})
+
+	latest := tree(t, map[string]string{
+		"go.mod":     "module example.com/foo\n",
+		"foo.go":     "package foo\nfunc Foo() {}\nfunc Baz() {}\n",
+		"bar/bar.go": "package bar\nfunc Bar(string) {}\n",
+	})
+
+	for _, engine := range []Engine{AST, Types} {
+		t.Run(string(engine), func(t *testing.T) {
+			report, err := CompareDirs(context.Background(), previous, latest, Options{Engine: engine})
+			if err != nil {
+				t.Fatalf("expected no error; got %s", err)
+			}
+
+			if report.Type != Major {
+				t.Errorf("expected difference of %s; got %s", Major, report.Type)
+			}
+
+			if len(report.Changes) != 2 {
+				t.Fatalf("expected 2 changes; got %d", len(report.Changes))
+			}
+
+			for _, change := range report.Changes {
+				switch change.Symbol {
+				case "Baz":
+					if change.Package != "example.com/foo" || change.Latest == nil || change.Latest.Source != "func Baz()" {
+						t.Errorf("unexpected change %+v", change)
+					}
+				case "Bar":
+					if change.Package != "example.com/foo/bar" || change.Previous == nil || change.Previous.Line != 2 {
+						t.Errorf("unexpected change %+v", change)
+					}
+				default:
+					t.Errorf("unexpected change of %s", change.Symbol)
+				}
+			}
+		})
+	}
+
+	report, err := CompareDirs(context.Background(), previous, latest, Options{Filter: []Type{Minor}})
+	if err != nil {
+		t.Fatalf("expected no error; got %s", err)
+	}
+
+	if report.Type != Major || len(report.Changes) != 1 || report.Changes[0].Symbol != "Baz" {
+		t.Errorf("expected filter to only keep the minor change; got %+v", report)
+	}
+}
+
+func TestCompareDirsModule(t *testing.T) {
+	previous := tree(t, map[string]string{
+		"go.mod":              "module example.com/foo // comment\n",
+		"bar/bar.go":          "package bar\nfunc Bar() {}\n",
+		"testdata/foo.go":     "package foo\nfunc Foo() {}\n",
+		"vendor/a/a.go":       "package a\nfunc A() {}\n",
+		"_example/main.go":    "package main\nfunc A() {}\n",
+		".hidden/hidden.go":   "package hidden\nfunc A() {}\n",
+		"nested/go.mod":       "module example.com/nested\n",
+		"nested/nested.go":    "package nested\nfunc A() {}\n",
+		"bar/baz/go.mod":      "module example.com/baz\n",
+		"bar/baz/baz.go":      "package baz\nfunc A() {}\n",
+		"bar/qux/testdata.go": "package qux\nfunc A() {}\n",
+	})
+
+	latest := tree(t, map[string]string{
+		"go.mod":              "module example.com/foo // comment\n",
+		"bar/bar.go":          "package bar\nfunc Bar() {}\n",
+		"bar/qux/testdata.go": "package qux\nfunc A() {}\n",
+	})
+
+	report, err := CompareDirs(context.Background(), previous, latest, Options{})
+	if err != nil {
+		t.Fatalf("expected no error; got %s", err)
+	}
+
+	if report.Type != Patch {
+		t.Errorf("expected difference of %s; got %+v", Patch, report.Changes)
+	}
+
+	report, err = CompareDirs(context.Background(), filepath.Join(previous, "bar"), filepath.Join(latest, "bar"), Options{
+		Engine: Types,
+	})
+	if err != nil {
+		t.Fatalf("expected no error; got %s", err)
+	}
+
+	if report.Type != Patch {
+		t.Errorf("expected difference of %s; got %+v", Patch, report.Changes)
+	}
+
+	os.WriteFile(filepath.Join(latest, "bar", "qux", "testdata.go"), []byte("package qux\n"), 0644)
+
+	report, err = CompareDirs(context.Background(), filepath.Join(previous, "bar"), filepath.Join(latest, "bar"), Options{})
+	if err != nil {
+		t.Fatalf("expected no error; got %s", err)
+	}
+
+	if len(report.Changes) != 1 || report.Changes[0].Package != "example.com/foo/bar/qux" {
+		t.Errorf("expected removal in example.com/foo/bar/qux; got %+v", report.Changes)
+	}
+}
+
+func TestCompareDirsModules(t *testing.T) {
+	previous := tree(t, map[string]string{
+		"a/go.mod":   "module example.com/a\n",
+		"a/a.go":     "package a\nfunc A() {}\n",
+		"b/go.mod":   "module example.com/b\n",
+		"b/b.go":     "package b\nimport \"example.com/b/c\"\nfunc B(c.C) {}\n",
+		"b/c/c.go":   "package c\ntype C int\n",
+		"b/d/go.mod": "module example.com/d\n",
+		"b/d/d.go":   "package d\nfunc D() {}\n",
+	})
+
+	latest := tree(t, map[string]string{
+		"a/go.mod": "module example.com/a\n",
+		"a/a.go":   "package a\n",
+		"b/go.mod": "module example.com/b\n",
+		"b/b.go":   "package b\nimport \"example.com/b/c\"\nfunc B(c.C) {}\n",
+		"b/c/c.go": "package c\ntype C int\n",
+	})
+
+	for _, engine := range []Engine{AST, Types} {
+		t.Run(string(engine), func(t *testing.T) {
+			report, err := CompareDirs(context.Background(), previous, latest, Options{Engine: engine})
+			if err != nil {
+				t.Fatalf("expected no error; got %s", err)
+			}
+
+			if report.Type != Major || len(report.Changes) != 1 || report.Changes[0].Package != "example.com/a" {
+				t.Errorf("expected removal in example.com/a; got %+v", report.Changes)
+			}
+		})
+	}
+}
+
+func TestCompareDirsInternal(t *testing.T) {
+	previous := tree(t, map[string]string{
+		"go.mod":               "module example.com/foo\n",
+		"internal/bar/bar.go":  "package bar\nfunc Bar() {}\n",
+		"cmd/foo/main.go":      "package main\nfunc Foo() {}\n",
+		"internals/baz/baz.go": "package baz\nfunc Baz() {}\n",
+	})
+
+	latest := tree(t, map[string]string{
+		"go.mod":               "module example.com/foo\n",
+		"internal/bar/bar.go":  "package bar\n",
+		"cmd/foo/main.go":      "package main\n",
+		"internals/baz/baz.go": "package baz\nfunc Baz() {}\n",
+	})
+
+	report, err := CompareDirs(context.Background(), previous, latest, Options{})
+	if err != nil {
+		t.Fatalf("expected no error; got %s", err)
+	}
+
+	if report.Type != Patch || len(report.Changes) != 2 {
+		t.Errorf("expected two patches; got %+v", report)
+	}
+
+	for _, change := range report.Changes {
+		if !change.Internal {
+			t.Errorf("expected change of %s to be internal", change.Symbol)
+		}
+	}
+
+	report, err = CompareDirs(context.Background(), previous, latest, Options{IncludeInternal: true})
+	if err != nil {
+		t.Fatalf("expected no error; got %s", err)
+	}
+
+	if report.Type != Major {
+		t.Errorf("expected difference of %s; got %s", Major, report.Type)
+	}
+}
+
+func TestCompareDirsParseError(t *testing.T) {
+	previous := tree(t, map[string]string{
+		"foo.go": "package foo\nfunc Foo() {}\n",
+	})
+
+	latest := tree(t, map[string]string{
+		"foo.go": "package foo\nfunc Foo( {}\n",
+	})
+
+	_, err := CompareDirs(context.Background(), previous, latest, Options{})
+
+	var perr *ParseError
+	if !errors.As(err, &perr) {
+		t.Fatalf("expected parse error; got %v", err)
+	}
+
+	if perr.Version != "latest" || perr.Position.Line != 2 {
+		t.Errorf("expected error in latest version at line 2; got %s", perr)
+	}
+
+	report, err := CompareDirs(context.Background(), previous, latest, Options{KeepGoing: true})
+	if err != nil {
+		t.Fatalf("expected no error; got %s", err)
+	}
+
+	if report.Type != Unknown {
+		t.Errorf("expected difference of %s; got %s", Unknown, report.Type)
+	}
+}
+
+func TestCompareDirsImports(t *testing.T) {
+	previous := tree(t, map[string]string{
+		"go.mod":   "module example.com/foo\n",
+		"a/a.go":   "package a\nimport \"example.com/foo/b\"\nfunc F(x b.T) {}\n",
+		"c/c.go":   "package c\nimport \"example.com/foo/b\"\ntype C = b.T\n",
+		"b/b.go":   "package b\ntype T int\ntype U string\n",
+		"b/doc.go": "package b\n",
+	})
+
+	latest := tree(t, map[string]string{
+		"go.mod":   "module example.com/foo\n",
+		"a/a.go":   "package a\nimport \"example.com/foo/b\"\nfunc F(x b.U) {}\n",
+		"c/c.go":   "package c\nimport \"example.com/foo/b\"\ntype C = b.U\n",
+		"b/b.go":   "package b\ntype T int\ntype U string\n",
+		"b/doc.go": "package b\n",
+	})
+
+	for _, engine := range []Engine{AST, Types} {
+		t.Run(string(engine), func(t *testing.T) {
+			report, err := CompareDirs(context.Background(), previous, latest, Options{Engine: engine})
+			if err != nil {
+				t.Fatalf("expected no error; got %s", err)
+			}
+
+			symbols := []string{}
+			for _, change := range report.Changes {
+				symbols = append(symbols, change.Symbol)
+			}
+
+			if report.Type != Major || strings.Join(symbols, ", ") != "F, C" {
+				t.Errorf("expected major changes of F and C; got %+v", report.Changes)
+			}
+		})
+	}
+
+	os.WriteFile(filepath.Join(latest, "a", "a.go"), []byte("package a\nimport \"example.com/foo/missing\"\nfunc F(x missing.T) {}\n"), 0644)
+
+	_, err := CompareDirs(context.Background(), previous, latest, Options{Engine: Types})
+
+	var ierr *ImportError
+	if !errors.As(err, &ierr) {
+		t.Fatalf("expected import error; got %v", err)
+	}
+
+	if ierr.Version != "latest" || ierr.Import != "example.com/foo/missing" || ierr.Position.Line != 2 {
+		t.Errorf("expected error importing example.com/foo/missing in latest version at line 2; got %s", ierr)
+	}
+
+	report, err := CompareDirs(context.Background(), previous, latest, Options{Engine: Types, KeepGoing: true})
+	if err != nil {
+		t.Fatalf("expected no error; got %s", err)
+	}
+
+	if report.Type != Unknown {
+		t.Errorf("expected difference of %s; got %s", Unknown, report.Type)
+	}
+}
+
+func TestCompareDirsNote(t *testing.T) {
+	previous := tree(t, map[string]string{
+		"go.mod": "module example.com/foo\n",
+		"foo.go": "package foo\ntype Option func()\nfunc New() {}\nfunc Sum(a int) {}\n",
+	})
+
+	latest := tree(t, map[string]string{
+		"go.mod": "module example.com/foo\n",
+		"foo.go": "package foo\ntype Option func()\nfunc New(...Option) {}\nfunc Sum(a ...int) {}\n",
+	})
+
+	for _, engine := range []Engine{AST, Types} {
+		t.Run(string(engine), func(t *testing.T) {
+			report, err := CompareDirs(context.Background(), previous, latest, Options{Engine: engine})
+			if err != nil {
+				t.Fatalf("expected no error; got %s", err)
+			}
+
+			if report.Type != Minor || len(report.Changes) != 2 {
+				t.Fatalf("expected two minor changes; got %+v", report.Changes)
+			}
+
+			for _, change := range report.Changes {
+				if change.Note == "" {
+					t.Errorf("expected a note for %s; got none", change.Symbol)
+				}
+			}
+		})
+	}
+}
+
+func TestCompareDirsJobs(t *testing.T) {
+	previous, latest := map[string]string{}, map[string]string{}
+	for _, pkg := range []string{"a", "b", "c", "d", "e", "f", "g", "h"} {
+		previous[pkg+"/"+pkg+".go"] = "package " + pkg + "\nfunc Foo() {}\n"
+		latest[pkg+"/"+pkg+".go"] = "package " + pkg + "\n"
+	}
+
+	a, b := tree(t, previous), tree(t, latest)
+
+	for _, jobs := range []int{1, 3, 8} {
+		report, err := CompareDirs(context.Background(), a, b, Options{Jobs: jobs})
+		if err != nil {
+			t.Fatalf("expected no error; got %v", err)
+		}
+
+		packages := []string{}
+		for _, change := range report.Changes {
+			packages = append(packages, change.Package)
+		}
+
+		if expected := "a b c d e f g h"; strings.Join(packages, " ") != expected {
+			t.Errorf("expected changes of %s in order with %d jobs; got %v", expected, jobs, packages)
+		}
+	}
+
+	latest["c/c.go"] = "package c\nfunc Foo( {}\n"
+	latest["f/f.go"] = "package f\nfunc Foo( {}\n"
+	b = tree(t, latest)
+
+	for i := 0; i < 10; i++ {
+		_, err := CompareDirs(context.Background(), a, b, Options{Jobs: 8})
+
+		var perr *ParseError
+		if !errors.As(err, &perr) || perr.Package != "c" {
+			t.Fatalf("expected parse error of first failing package c; got %v", err)
+		}
+	}
+}
+
+func TestCompareDirsOrder(t *testing.T) {
+	previous := tree(t, map[string]string{
+		"go.mod":     "module example.com/foo\n",
+		"b.go":       "package foo\nfunc D() {}\nfunc C() {}\n",
+		"a.go":       "package foo\nfunc B() {}\n",
+		"bar/bar.go": "package bar\nfunc A() {}\n",
+	})
+
+	latest := tree(t, map[string]string{
+		"go.mod":     "module example.com/foo\n",
+		"b.go":       "package foo\nfunc E() {}\n",
+		"a.go":       "package foo\nfunc A() {}\nfunc B(int) {}\n",
+		"bar/bar.go": "package bar\n",
+	})
+
+	expected := "example.com/foo a.go A, example.com/foo a.go B, " +
+		"example.com/foo b.go D, example.com/foo b.go E, example.com/foo b.go C, " +
+		"example.com/foo/bar bar.go A"
+
+	for _, engine := range []Engine{AST, Types} {
+		for i := 0; i < 5; i++ {
+			report, err := CompareDirs(context.Background(), previous, latest, Options{Engine: engine})
+			if err != nil {
+				t.Fatalf("expected no error; got %v", err)
+			}
+
+			changes := []string{}
+			for _, change := range report.Changes {
+				file, _, _ := change.position()
+				changes = append(changes, change.Package+" "+file+" "+change.Symbol)
+			}
+
+			if actual := strings.Join(changes, ", "); actual != expected {
+				t.Fatalf("expected changes in order %s with %s engine; got %s", expected, engine, actual)
+			}
+		}
+	}
+}
+
+func TestCompareDirsSuppressions(t *testing.T) {
+	previous := tree(t, map[string]string{
+		"go.mod":                "module example.com/foo\n",
+		"foo.go":                "package foo\nfunc Foo() {}\nfunc Bar() {}\n",
+		"experimental/x/x.go":   "package x\nfunc X() {}\n",
+		"experimental/y/y/y.go": "package y\nfunc Y() {}\n",
+	})
+
+	latest := tree(t, map[string]string{
+		"go.mod":                "module example.com/foo\n",
+		"foo.go":                "package foo\nfunc Foo(int) {}\nfunc Baz() {}\n",
+		"experimental/x/x.go":   "package x\n",
+		"experimental/y/y/y.go": "package y\n",
+	})
+
+	report, err := CompareDirs(context.Background(), previous, latest, Options{
+		Suppressions: []Suppression{
+			{Symbol: "Foo", Package: "example.com/foo", Reason: "foo needs an int", Allow: Major},
+			{Symbol: "Bar", Reason: "bar is too minor", Allow: Minor},
+			{Package: "example.com/foo/experimental/...", Reason: "experimental", Allow: Major},
+			{Symbol: "Qux", Reason: "qux is gone", Allow: Major},
+		},
+	})
+
+	if err != nil {
+		t.Fatalf("expected no error; got %v", err)
+	}
+
+	if report.Type != Major {
+		t.Errorf("expected type of MAJOR from the removal of Bar; got %s", report.Type)
+	}
+
+	suppressed := map[string]string{}
+	for _, change := range report.Changes {
+		if change.Suppressed != "" {
+			if change.Type != Patch {
+				t.Errorf("expected suppressed change of %s to be a patch; got %s", change.Symbol, change.Type)
+			}
+			suppressed[change.Symbol] = change.Suppressed
+		}
+	}
+
+	if len(suppressed) != 3 || suppressed["Foo"] != "foo needs an int" ||
+		suppressed["X"] != "experimental" || suppressed["Y"] != "experimental" {
+		t.Errorf("expected Foo, X and Y to be suppressed; got %v", suppressed)
+	}
+
+	expected := []string{
+		"suppression of Bar allows MINOR changes, Bar has a MAJOR change",
+		"suppression of Qux is stale, it matches no change",
+	}
+	if strings.Join(report.Warnings, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected warnings %v; got %v", expected, report.Warnings)
+	}
+
+	for _, s := range []Suppression{
+		{Symbol: "Foo", Allow: Major},
+		{Symbol: "Foo", Reason: "foo", Allow: Patch},
+		{Reason: "foo", Allow: Major},
+	} {
+		if _, err := CompareDirs(context.Background(), previous, latest, Options{Suppressions: []Suppression{s}}); !errors.Is(err, InvalidSuppression) {
+			t.Errorf("expected error of InvalidSuppression for %+v; got %v", s, err)
+		}
+	}
+}
+
+func TestCompareDirsIgnore(t *testing.T) {
+	previous := tree(t, map[string]string{
+		"go.mod":              "module example.com/foo\n",
+		"foo.go":              "package foo\ntype Foo struct {\n\tA int `json:\"a\"`\n}\n",
+		"examples/a/a.go":     "package a\nfunc A() {}\n",
+		"tools/gen/gen.go":    "package gen\nfunc Gen() {}\n",
+		"toolsets/set/set.go": "package set\nfunc Set() {}\n",
+	})
+
+	latest := tree(t, map[string]string{
+		"go.mod":              "module example.com/foo\n",
+		"foo.go":              "package foo\ntype Foo struct {\n\tA int `json:\"b\"`\n}\n",
+		"examples/a/a.go":     "package a\n",
+		"tools/gen/gen.go":    "package gen\n",
+		"toolsets/set/set.go": "package set\n",
+	})
+
+	report, err := CompareDirs(context.Background(), previous, latest, Options{
+		Ignore:     []string{"examples", "tools/..."},
+		Severities: map[string]Type{"json tag name has changed, altering the encoding": Minor},
+	})
+
+	if err != nil {
+		t.Fatalf("expected no error; got %v", err)
+	}
+
+	packages := []string{}
+	for _, change := range report.Changes {
+		packages = append(packages, change.Package)
+	}
+
+	if expected := "example.com/foo example.com/foo/toolsets/set"; strings.Join(packages, " ") != expected {
+		t.Errorf("expected changes to %s; got %v", expected, packages)
+	}
+
+	if len(report.Changes) != 2 || report.Changes[0].Type != Minor {
+		t.Errorf("expected tag change to be overridden as MINOR; got %v", report.Changes)
+	}
+	_, err = CompareDirs(context.Background(), previous, latest, Options{
+		Severities: map[string]Type{"json tag name has changed": Minor},
+	})
+
+	if !errors.Is(err, InvalidRule) {
+		t.Errorf("expected error of InvalidRule; got %v", err)
+	}
+}
+
+func TestCompareDirsCanceled(t *testing.T) {
+	dir := tree(t, map[string]string{
+		"foo.go": "package foo\n",
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := CompareDirs(ctx, dir, dir, Options{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context to be canceled; got %v", err)
+	}
+
+	if _, err := CompareDirs(context.Background(), dir, dir, Options{Engine: "foo"}); !errors.Is(err, UnknownEngine) {
+		t.Errorf("expected unknown engine; got %v", err)
+	}
+}
@@ -0,0 +1,9 @@
+// Package semver automates semantic versioning by comparing the exported
+// api of two versions of a Go module.
+//
+//	report, err := semver.CompareDirs(ctx, "path/to/v1.0.0", "path/to/latest", semver.Options{})
+//	if err != nil {
+//		return err
+//	}
+//	next := semver.MustParse("v1.0.0").Bump(report.Type)
+package semver
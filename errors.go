@@ -0,0 +1,87 @@
+package semver
+
+import (
+	"errors"
+	"fmt"
+	"go/scanner"
+	"go/token"
+
+	"github.com/quartercastle/semver/internal/types"
+)
+
+const (
+	InvalidVersion     Error = "invalid semantic version"
+	InvalidType        Error = "invalid type of change"
+	UnknownEngine      Error = "unknown comparison engine"
+	InvalidSuppression Error = "invalid suppression"
+	InvalidRule        Error = "invalid rule"
+)
+
+type Error string
+
+func (e Error) Error() string {
+	return string(e)
+}
+
+// ParseError reports a package of a version which could not be parsed.
+type ParseError struct {
+	// Version is either "previous" or "latest".
+	Version  string
+	Package  string
+	Position token.Position
+	Err      error
+}
+
+func newParseError(version, path, dir string, err error) *ParseError {
+	e := &ParseError{
+		Version:  version,
+		Package:  path,
+		Position: token.Position{Filename: dir},
+		Err:      err,
+	}
+
+	var list scanner.ErrorList
+	if errors.As(err, &list) && len(list) > 0 {
+		e.Position, e.Err = list[0].Pos, errors.New(list[0].Msg)
+	}
+
+	return e
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("could not parse %s version: %s: %s", e.Version, e.Position, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// ImportError reports an import of a package of a version which could not
+// be resolved when type checking it, so changes of the types depending on
+// it can't be told.
+type ImportError struct {
+	// Version is either "previous" or "latest".
+	Version  string
+	Package  string
+	Import   string
+	Position token.Position
+	Err      error
+}
+
+func newImportError(version, path string, fset *token.FileSet, err *types.ImportError) *ImportError {
+	return &ImportError{
+		Version:  version,
+		Package:  path,
+		Import:   err.Path,
+		Position: fset.Position(err.Pos),
+		Err:      err.Err,
+	}
+}
+
+func (e *ImportError) Error() string {
+	return fmt.Sprintf("could not import %s in %s version: %s: %s", e.Import, e.Version, e.Position, e.Err)
+}
+
+func (e *ImportError) Unwrap() error {
+	return e.Err
+}
@@ -1,8 +1,10 @@
 package ast
 
 import (
+	"fmt"
 	"go/ast"
 	"reflect"
+	"strings"
 )
 
 type Node = ast.Node
@@ -14,11 +16,26 @@ func (t Type) String() string {
 	return types[t]
 }
 
+func (t Type) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+func (t *Type) UnmarshalText(text []byte) error {
+	for k, v := range types {
+		if strings.EqualFold(v, string(text)) {
+			*t = k
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid type of change: %q", text)
+}
+
 var (
 	types = map[Type]string{
-		Patch: "PATCH",
-		Minor: "MINOR",
-		Major: "MAJOR",
+		Patch:   "PATCH",
+		Minor:   "MINOR",
+		Major:   "MAJOR",
+		Unknown: "UNKNOWN",
 	}
 )
 
@@ -26,6 +43,9 @@ const (
 	Patch Type = iota
 	Minor
 	Major
+	// Unknown is used when a change can't be classified, like when a
+	// package can't be parsed, and outranks every other type of change.
+	Unknown
 )
 
 type comparator func(previous, latest Node) Diff
@@ -46,6 +66,7 @@ func Compare(previous, latest ast.Node) Diff {
 		return diff.Add(Change{
 			Type:   Minor,
 			Reason: "package has been added",
+			Symbol: packageName(latest),
 			Latest: latest,
 		})
 	}
@@ -54,6 +75,7 @@ func Compare(previous, latest ast.Node) Diff {
 		return diff.Add(Change{
 			Type:     Major,
 			Reason:   "package has been removed",
+			Symbol:   packageName(previous),
 			Previous: previous,
 		})
 	}
@@ -69,3 +91,13 @@ func Compare(previous, latest ast.Node) Diff {
 		compareTypeSpec,
 	))
 }
+
+func packageName(node Node) string {
+	switch n := node.(type) {
+	case *ast.Package:
+		return n.Name
+	case *ast.File:
+		return n.Name.Name
+	}
+	return ""
+}
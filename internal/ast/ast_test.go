@@ -392,7 +392,7 @@ func TestCompare(t *testing.T) {
 				"	Bar float64 = 1.0",
 				")",
 			},
-			Patch,
+			Major,
 		},
 		{
 			"exported const inferred type",
@@ -408,7 +408,7 @@ func TestCompare(t *testing.T) {
 				"	Bar int = 1",
 				")",
 			},
-			Patch,
+			Major,
 		},
 		{
 			"exported var paran expr",
@@ -545,7 +545,7 @@ func TestCompare(t *testing.T) {
 				"	Bar int",
 				"}",
 			},
-			Major,
+			Minor,
 		},
 		{
 			"addition of private field",
@@ -562,7 +562,7 @@ func TestCompare(t *testing.T) {
 				"	Bar int",
 				"}",
 			},
-			Patch,
+			Major,
 		},
 		{
 			"removal of private field",
@@ -581,6 +581,110 @@ func TestCompare(t *testing.T) {
 			},
 			Patch,
 		},
+		{
+			"removal of field in struct",
+			[]string{
+				"type Foo struct {",
+				"	Foo int",
+				"	Bar int",
+				"}",
+			},
+			[]string{
+				"type Foo struct {",
+				"	Foo int",
+				"}",
+			},
+			Major,
+		},
+		{
+			"change of field type in struct",
+			[]string{
+				"type Foo struct {",
+				"	Foo, Bar int",
+				"}",
+			},
+			[]string{
+				"type Foo struct {",
+				"	Foo int",
+				"	Bar string",
+				"}",
+			},
+			Major,
+		},
+		{
+			"splitting field declaration in struct",
+			[]string{
+				"type Foo struct {",
+				"	Foo, Bar int",
+				"}",
+			},
+			[]string{
+				"type Foo struct {",
+				"	Foo int",
+				"	Bar int",
+				"}",
+			},
+			Patch,
+		},
+		{
+			"change of field tag in struct",
+			[]string{
+				"type Foo struct {",
+				"	Foo int `json:\"foo\"`",
+				"}",
+			},
+			[]string{
+				"type Foo struct {",
+				"	Foo int `json:\"bar\"`",
+				"}",
+			},
+			Major,
+		},
+		{
+			"swapping fields in struct",
+			[]string{
+				"type Foo struct {",
+				"	Foo int",
+				"	Bar int",
+				"}",
+			},
+			[]string{
+				"type Foo struct {",
+				"	Bar int",
+				"	Foo int",
+				"}",
+			},
+			Major,
+		},
+		{
+			"addition of embedded field in struct",
+			[]string{
+				"type Foo struct {",
+				"	Foo int",
+				"}",
+			},
+			[]string{
+				"type Foo struct {",
+				"	Foo int",
+				"	*bytes.Buffer",
+				"}",
+			},
+			Minor,
+		},
+		{
+			"change of embedded field to pointer in struct",
+			[]string{
+				"type Foo struct {",
+				"	bytes.Buffer",
+				"}",
+			},
+			[]string{
+				"type Foo struct {",
+				"	*bytes.Buffer",
+				"}",
+			},
+			Major,
+		},
 		{
 			"addition of type aliasing",
 			[]string{
@@ -701,6 +805,153 @@ func TestCompare(t *testing.T) {
 			},
 			Major,
 		},
+		{
+			"addition of method to sealed interface",
+			[]string{
+				"type Foo interface {",
+				"	Bar()",
+				"	bar()",
+				"}",
+			},
+			[]string{
+				"type Foo interface {",
+				"	Bar()",
+				"	Baz()",
+				"	bar()",
+				"}",
+			},
+			Minor,
+		},
+		{
+			"removal of method from sealed interface",
+			[]string{
+				"type Foo interface {",
+				"	Bar()",
+				"	bar()",
+				"}",
+			},
+			[]string{
+				"type Foo interface {",
+				"	bar()",
+				"}",
+			},
+			Major,
+		},
+		{
+			"sealing an interface",
+			[]string{
+				"type Foo interface {",
+				"	Bar()",
+				"}",
+			},
+			[]string{
+				"type Foo interface {",
+				"	Bar()",
+				"	bar()",
+				"}",
+			},
+			Major,
+		},
+		{
+			"removal of unexported method from sealed interface",
+			[]string{
+				"type Foo interface {",
+				"	Bar()",
+				"	bar()",
+				"}",
+			},
+			[]string{
+				"type Foo interface {",
+				"	Bar()",
+				"}",
+			},
+			Patch,
+		},
+		{
+			"reordering methods in interface",
+			[]string{
+				"type Foo interface {",
+				"	Bar()",
+				"	Baz()",
+				"}",
+			},
+			[]string{
+				"type Foo interface {",
+				"	Baz()",
+				"	Bar()",
+				"}",
+			},
+			Patch,
+		},
+		{
+			"addition of embedded interface",
+			[]string{
+				"type Foo interface {",
+				"	Bar()",
+				"}",
+			},
+			[]string{
+				"type Foo interface {",
+				"	Bar()",
+				"	io.Reader",
+				"}",
+			},
+			Major,
+		},
+		{
+			"reordering fields of struct with unexported field",
+			[]string{"type Foo struct {", "	Foo int", "	Bar int", "	baz int", "}"},
+			[]string{"type Foo struct {", "	Bar int", "	Foo int", "	baz int", "}"},
+			Patch,
+		},
+		{
+			"addition of field in the middle of struct with unexported field",
+			[]string{"type Foo struct {", "	Foo int", "	Bar int", "	baz int", "}"},
+			[]string{"type Foo struct {", "	Foo int", "	Qux int", "	Bar int", "	baz int", "}"},
+			Minor,
+		},
+		{
+			"removal of field from struct with unexported field",
+			[]string{"type Foo struct {", "	Foo int", "	Bar int", "	baz int", "}"},
+			[]string{"type Foo struct {", "	Foo int", "	baz int", "}"},
+			Major,
+		},
+		{
+			"appending slice field to comparable struct",
+			[]string{"type Foo struct {", "	Foo int", "}"},
+			[]string{"type Foo struct {", "	Foo int", "	Bar []int", "}"},
+			Major,
+		},
+		{
+			"appending unexported func field to comparable struct",
+			[]string{"type Foo struct {", "	Foo int", "	bar int", "}"},
+			[]string{"type Foo struct {", "	Foo int", "	bar int", "	baz func()", "}"},
+			Major,
+		},
+		{
+			"appending field of incomparable named type to comparable struct",
+			[]string{"type List []int", "type Foo struct {", "	Foo int", "}"},
+			[]string{"type List []int", "type Foo struct {", "	Foo int", "	Bar List", "}"},
+			Major,
+		},
+		{
+			"appending map field to incomparable struct",
+			[]string{"type Foo struct {", "	Foo []int", "}"},
+			[]string{"type Foo struct {", "	Foo []int", "	Bar map[string]int", "}"},
+			Minor,
+		},
+		{
+			"addition of omitempty to json tag",
+			[]string{"type Foo struct {", "	Foo int `json:\"foo\"`", "}"},
+			[]string{"type Foo struct {", "	Foo int `json:\"foo,omitempty\"`", "}"},
+			Major,
+		},
+		{
+			"addition of unrelated tag key",
+			[]string{"type Foo struct {", "	Foo int `json:\"foo\"`", "}"},
+			[]string{"type Foo struct {", "	Foo int `json:\"foo\" validate:\"required\"`", "}"},
+			Patch,
+		},
 	}
 
 	for _, c := range tc {
@@ -718,10 +969,524 @@ func TestCompare(t *testing.T) {
 					actual[len(actual)-1].Reason,
 				)
 			}
+
+			for _, change := range Compare(previous, latest) {
+				if !KnownReason(change.Reason) {
+					t.Errorf("expected reason %q to be known", change.Reason)
+				}
+			}
 		})
 	}
 }
 
+func TestSymbol(t *testing.T) {
+	tc := []struct {
+		title            string
+		previous, latest []string
+		expected         string
+	}{
+		{
+			"function",
+			[]string{"func Foo()"},
+			[]string{"func Foo(string)"},
+			"Foo",
+		},
+		{
+			"method on pointer receiver",
+			[]string{"type Foo struct{}", "func (f *Foo) Bar()"},
+			[]string{"type Foo struct{}", "func (f *Foo) Bar(string)"},
+			"Foo.Bar",
+		},
+		{
+			"method on generic receiver",
+			[]string{"type Foo[T any] struct{}", "func (f Foo[T]) Bar()"},
+			[]string{"type Foo[T any] struct{}"},
+			"Foo.Bar",
+		},
+		{
+			"type spec",
+			[]string{"type Foo int"},
+			[]string{"type Foo string"},
+			"Foo",
+		},
+		{
+			"value spec",
+			[]string{"var Foo, Bar = 1, 2"},
+			[]string{"var Foo, Bar = 2, 1"},
+			"Foo, Bar",
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.title, func(t *testing.T) {
+			previous, latest, err := parse(c.previous, c.latest)
+
+			if err != nil {
+				t.Error(err)
+			}
+
+			actual := Compare(previous, latest)
+			if len(actual) != 1 {
+				t.Fatalf("expected a single change; got %d", len(actual))
+			}
+
+			if actual[0].Symbol != c.expected {
+				t.Errorf("expected symbol %s; got %s", c.expected, actual[0].Symbol)
+			}
+		})
+	}
+}
+
+func TestSignature(t *testing.T) {
+	tc := []struct {
+		title            string
+		previous, latest []string
+		expected         []string
+	}{
+		{
+			"renamed parameter",
+			[]string{"func Foo(a int) {}"},
+			[]string{"func Foo(b int) {}"},
+			[]string{"parameter has been renamed"},
+		},
+		{
+			"renamed result",
+			[]string{"func Foo() (n int) { return }"},
+			[]string{"func Foo() (m int) { return }"},
+			[]string{"result has been renamed"},
+		},
+		{
+			"renamed type parameter",
+			[]string{"func Foo[T any, S ~[]T](s S) T { var t T; return t }"},
+			[]string{"func Foo[E any, S ~[]E](s S) E { var e E; return e }"},
+			[]string{"type parameter has been renamed"},
+		},
+		{
+			"changed type parameters",
+			[]string{"func Foo[T any](T) {}"},
+			[]string{"func Foo[T comparable](T) {}"},
+			[]string{"type parameters have changed"},
+		},
+		{
+			"added parameter",
+			[]string{"func Foo(a int) {}"},
+			[]string{"func Foo(s string, a int) {}"},
+			[]string{"parameter has been added"},
+		},
+		{
+			"removed parameter",
+			[]string{"func Foo(a, b int) {}"},
+			[]string{"func Foo(a int) {}"},
+			[]string{"parameter has been removed"},
+		},
+		{
+			"changed parameter type",
+			[]string{"func Foo(a int) {}"},
+			[]string{"func Foo(a string) {}"},
+			[]string{"parameter type has changed"},
+		},
+		{
+			"changed parameter type and added parameter",
+			[]string{"func Foo(a int) {}"},
+			[]string{"func Foo(a string, b bool) {}"},
+			[]string{"parameter type has changed", "parameter has been added"},
+		},
+		{
+			"added result",
+			[]string{"func Foo() {}"},
+			[]string{"func Foo() error { return nil }"},
+			[]string{"result has been added"},
+		},
+		{
+			"removed result",
+			[]string{"func Foo() (int, error) { return 0, nil }"},
+			[]string{"func Foo() int { return 0 }"},
+			[]string{"result has been removed"},
+		},
+		{
+			"changed result type",
+			[]string{"func Foo() int { return 0 }"},
+			[]string{"func Foo() string { return \"\" }"},
+			[]string{"result type has changed"},
+		},
+		{
+			"introduced variadic parameter",
+			[]string{"func Foo(a []int) {}"},
+			[]string{"func Foo(a ...int) {}"},
+			[]string{"variadic parameter has been introduced"},
+		},
+		{
+			"last parameter turned variadic",
+			[]string{"func Foo(s string, a int) {}"},
+			[]string{"func Foo(s string, a ...int) {}"},
+			[]string{"variadic parameter has been introduced"},
+		},
+		{
+			"variadic parameter turned into a slice",
+			[]string{"func Foo(a ...int) {}"},
+			[]string{"func Foo(a []int) {}"},
+			[]string{"variadic parameter has been removed"},
+		},
+		{
+			"variadic parameter turned into its element type",
+			[]string{"func Foo(a ...int) {}"},
+			[]string{"func Foo(a int) {}"},
+			[]string{"variadic parameter has been removed"},
+		},
+		{
+			"removed variadic parameter",
+			[]string{"func Foo(a int, b ...int) {}"},
+			[]string{"func Foo(a int) {}"},
+			[]string{"variadic parameter has been removed"},
+		},
+		{
+			"appended variadic parameter",
+			[]string{"type Option func()", "func Foo(a int) {}"},
+			[]string{"type Option func()", "func Foo(a int, opts ...Option) {}"},
+			[]string{"variadic parameter has been appended"},
+		},
+		{
+			"appended variadic parameter and changed parameter type",
+			[]string{"type Option func()", "func Foo(a int) {}"},
+			[]string{"type Option func()", "func Foo(a string, opts ...Option) {}"},
+			[]string{"variadic parameter has been appended", "parameter type has changed"},
+		},
+		{
+			"widened parameter to the empty interface",
+			[]string{"func Foo(a int, b string) {}"},
+			[]string{"func Foo(a any, b interface{}) {}"},
+			[]string{"parameter has been widened to an interface"},
+		},
+		{
+			"narrowed parameter from the empty interface",
+			[]string{"func Foo(a any) {}"},
+			[]string{"func Foo(a int) {}"},
+			[]string{"parameter type has changed"},
+		},
+		{
+			"widened variadic parameter",
+			[]string{"func Foo(a ...int) {}"},
+			[]string{"func Foo(a ...any) {}"},
+			[]string{"parameter type has changed"},
+		},
+		{
+			"result changed to the empty interface",
+			[]string{"func Foo() int { return 0 }"},
+			[]string{"func Foo() any { return 0 }"},
+			[]string{"result type has changed"},
+		},
+		{
+			"changed method parameter type",
+			[]string{"type Bar struct{}", "func (Bar) Foo(a int) {}"},
+			[]string{"type Bar struct{}", "func (Bar) Foo(a string) {}"},
+			[]string{"parameter type has changed"},
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.title, func(t *testing.T) {
+			previous, latest, err := parse(c.previous, c.latest)
+
+			if err != nil {
+				t.Error(err)
+			}
+
+			reasons := []string{}
+			for _, change := range Compare(previous, latest) {
+				reasons = append(reasons, change.Reason)
+			}
+
+			if strings.Join(reasons, ", ") != strings.Join(c.expected, ", ") {
+				t.Errorf("expected %v; got %v", c.expected, reasons)
+			}
+		})
+	}
+}
+
+func TestDiffTag(t *testing.T) {
+	tc := []struct {
+		title            string
+		previous, latest string
+		expected         []string
+	}{
+		{"unchanged", `json:"foo"`, `json:"foo"`, []string{}},
+		{"reformatted", `json:"foo"  xml:"foo"`, `json:"foo" xml:"foo"`, []string{}},
+		{"renamed json", `json:"foo"`, `json:"bar"`, []string{"json tag name has changed, altering the encoding"}},
+		{"added omitempty", `json:"foo"`, `json:"foo,omitempty"`, []string{"json tag options have changed, altering the encoding"}},
+		{"reordered options", `json:"foo,omitempty,string"`, `json:"foo,string,omitempty"`, []string{"json tag has changed"}},
+		{"skipped json", `json:"foo"`, `json:"-"`, []string{"json tag name has changed, altering the encoding"}},
+		{"added json naming the field", ``, `json:"Foo"`, []string{"json tag has been added"}},
+		{"added json renaming the field", ``, `json:"foo"`, []string{"json tag name has changed, altering the encoding"}},
+		{"added yaml naming the field", ``, `yaml:"foo"`, []string{"yaml tag has been added"}},
+		{"removed db", `db:"bar"`, ``, []string{"db tag name has changed, altering the encoding"}},
+		{"added unrelated key", `json:"foo"`, `json:"foo" validate:"required"`, []string{"validate tag has been added"}},
+		{"changed unrelated key", `validate:"required"`, `validate:"min=1"`, []string{"validate tag has changed"}},
+		{
+			"renamed xml and json",
+			`json:"foo" xml:"foo,attr"`,
+			`json:"bar" xml:"bar"`,
+			[]string{
+				"json tag name has changed, altering the encoding",
+				"xml tag name has changed, altering the encoding",
+				"xml tag options have changed, altering the encoding",
+			},
+		},
+		{"malformed", `json:"foo"`, `json:foo`, []string{"field tag has changed"}},
+	}
+
+	for _, c := range tc {
+		t.Run(c.title, func(t *testing.T) {
+			reasons := []string{}
+			for _, change := range DiffTag("Foo", c.previous, c.latest) {
+				reasons = append(reasons, change.Reason)
+			}
+
+			if strings.Join(reasons, ", ") != strings.Join(c.expected, ", ") {
+				t.Errorf("expected %v; got %v", c.expected, reasons)
+			}
+		})
+	}
+}
+
+func TestDiffStruct(t *testing.T) {
+	fields := func(list ...string) []Field[string] {
+		result := []Field[string]{}
+		for _, f := range list {
+			name, typ, _ := strings.Cut(f, " ")
+			result = append(result, Field[string]{Name: name, Type: typ})
+		}
+		return result
+	}
+	equal := func(a, b string) bool { return a == b }
+
+	tc := []struct {
+		title            string
+		previous, latest Struct[string]
+		expected         []string
+	}{
+		{"unchanged", Struct[string]{Fields: fields("A int")}, Struct[string]{Fields: fields("A int")}, []string{}},
+		{"removed", Struct[string]{Fields: fields("A int", "B int")}, Struct[string]{Fields: fields("A int")}, []string{"field has been removed"}},
+		{"changed type", Struct[string]{Fields: fields("A int")}, Struct[string]{Fields: fields("A string")}, []string{"field type has changed"}},
+		{"appended", Struct[string]{Fields: fields("A int")}, Struct[string]{Fields: fields("A int", "B int")}, []string{"field has been appended"}},
+		{"added in the middle", Struct[string]{Fields: fields("A int")}, Struct[string]{Fields: fields("B int", "A int")}, []string{"field has been added in the middle of struct"}},
+		{"added to keyed", Struct[string]{Fields: fields("A int"), Keyed: true}, Struct[string]{Fields: fields("B int", "A int"), Keyed: true}, []string{"field has been added to struct with unexported fields"}},
+		{"reordered", Struct[string]{Fields: fields("A int", "B int")}, Struct[string]{Fields: fields("B int", "A int")}, []string{"fields have been reordered"}},
+		{"no longer unkeyed", Struct[string]{Fields: fields("A int")}, Struct[string]{Fields: fields("A int"), Keyed: true}, []string{"struct can no longer be constructed with unkeyed literals"}},
+		{"reordered keyed", Struct[string]{Fields: fields("A int", "B int"), Keyed: true}, Struct[string]{Fields: fields("B int", "A int"), Keyed: true}, []string{"fields have been reordered in struct with unexported fields"}},
+		{"no longer comparable", Struct[string]{Comparable: true}, Struct[string]{}, []string{"struct is no longer comparable"}},
+	}
+
+	for _, c := range tc {
+		t.Run(c.title, func(t *testing.T) {
+			reasons := []string{}
+			for _, change := range DiffStruct("Foo", c.previous, c.latest, equal) {
+				reasons = append(reasons, change.Reason)
+				if change.Reason == "field has been removed" && change.Latest != nil {
+					t.Errorf("expected no latest node for a removed field; got %v", change.Latest)
+				}
+			}
+
+			if strings.Join(reasons, ", ") != strings.Join(c.expected, ", ") {
+				t.Errorf("expected %v; got %v", c.expected, reasons)
+			}
+		})
+	}
+}
+
+func TestConstant(t *testing.T) {
+	tc := []struct {
+		title            string
+		previous, latest []string
+		expected         []string
+	}{
+		{
+			"rewritten expression",
+			[]string{"const Foo = 1 << 2"},
+			[]string{"const Foo = 4"},
+			[]string{},
+		},
+		{
+			"changed value",
+			[]string{"const TypeMTK = \"PMTK\""},
+			[]string{"const TypeMTK = \"MTK\""},
+			[]string{"const has changed value"},
+		},
+		{
+			"changed type",
+			[]string{"const Foo int = 1"},
+			[]string{"const Foo int64 = 1"},
+			[]string{"const has changed type"},
+		},
+		{
+			"changed untyped kind",
+			[]string{"const Foo = 1"},
+			[]string{"const Foo = 1.5"},
+			[]string{"const has changed type"},
+		},
+		{
+			"typed with default type",
+			[]string{"const Foo = 1"},
+			[]string{"const Foo int = 1"},
+			[]string{"const has changed type"},
+		},
+		{
+			"untyped rune",
+			[]string{"const Foo = 'a'"},
+			[]string{"const Foo = 97"},
+			[]string{"const has changed type"},
+		},
+		{
+			"conversion to declared type",
+			[]string{"type Kind int", "const Foo = Kind(1)"},
+			[]string{"type Kind int", "const Foo Kind = 1"},
+			[]string{},
+		},
+		{
+			"typed by operand",
+			[]string{"const Foo int8 = 1", "const Bar = Foo + 1"},
+			[]string{"const Foo int8 = 1", "const Bar = 2"},
+			[]string{"const has changed type"},
+		},
+		{
+			"length of string",
+			[]string{"const Foo = len(\"foo\")"},
+			[]string{"const Foo = 3"},
+			[]string{},
+		},
+		{
+			"insertion into iota block",
+			[]string{"const (", "	Foo = iota", "	Bar", ")"},
+			[]string{"const (", "	Foo = iota", "	Baz", "	Bar", ")"},
+			[]string{"const has changed value", "value spec has been added"},
+		},
+		{
+			"unchanged typed iota block",
+			[]string{"type Kind int", "const (", "	Foo Kind = 1 << iota", "	Bar", ")"},
+			[]string{"type Kind int", "const (", "	Foo Kind = 1 << iota", "	Bar", ")"},
+			[]string{},
+		},
+		{
+			"unevaluable expression",
+			[]string{"const Foo = unsafe.Sizeof(0)"},
+			[]string{"const Foo = unsafe.Sizeof(int64(0))"},
+			[]string{"value spec has changed signature"},
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.title, func(t *testing.T) {
+			previous, latest, err := parse(c.previous, c.latest)
+
+			if err != nil {
+				t.Error(err)
+			}
+
+			reasons := []string{}
+			for _, change := range Compare(previous, latest) {
+				reasons = append(reasons, change.Reason)
+			}
+
+			if strings.Join(reasons, ", ") != strings.Join(c.expected, ", ") {
+				t.Errorf("expected %v; got %v", c.expected, reasons)
+			}
+		})
+	}
+}
+
+func TestKnownReason(t *testing.T) {
+	tc := []struct {
+		reason   string
+		expected bool
+	}{
+		{"const has changed value", true},
+		{"type has changed to a function", true},
+		{"result has been widened to an interface", true},
+		{"yaml tag options have changed, altering the encoding", true},
+		{"validate tag has been added", true},
+		{"const has changed vaule", false},
+		{" tag has been added", false},
+		{"field has been renamed", false},
+	}
+
+	for _, c := range tc {
+		t.Run(c.reason, func(t *testing.T) {
+			if actual := KnownReason(c.reason); actual != c.expected {
+				t.Errorf("expected %t; got %t", c.expected, actual)
+			}
+		})
+	}
+}
+
+func TestStructMembers(t *testing.T) {
+	previous, latest, _ := parse(
+		[]string{"type DBS struct {", "	DepthFeet float64", "	DepthMeters float64", "}"},
+		[]string{"type DBS struct {", "	DepthFeet string", "	DepthFeetUnit string", "	DepthMeters float64", "}"},
+	)
+
+	expected := []struct {
+		symbol, reason string
+	}{
+		{"DBS.DepthFeet", "field type has changed"},
+		{"DBS.DepthFeetUnit", "field has been added in the middle of struct"},
+	}
+
+	actual := Compare(previous, latest)
+	if len(actual) != len(expected) {
+		t.Fatalf("expected %d changes; got %d", len(expected), len(actual))
+	}
+
+	for i, e := range expected {
+		if actual[i].Symbol != e.symbol || actual[i].Reason != e.reason {
+			t.Errorf("expected %s: %s; got %s: %s", e.symbol, e.reason, actual[i].Symbol, actual[i].Reason)
+		}
+	}
+}
+
+func TestInterfaceMembers(t *testing.T) {
+	previous, latest, _ := parse(
+		[]string{"type Foo interface {", "	Bar()", "}"},
+		[]string{"type Foo interface {", "	Bar()", "	Baz()", "	bar()", "}"},
+	)
+
+	expected := []struct {
+		symbol, reason string
+	}{
+		{"Foo.Baz", "method has been added to interface, breaking implementers"},
+		{"Foo", "interface has been sealed by an unexported method"},
+	}
+
+	actual := Compare(previous, latest)
+	if len(actual) != len(expected) {
+		t.Fatalf("expected %d changes; got %d", len(expected), len(actual))
+	}
+
+	for i, e := range expected {
+		if actual[i].Symbol != e.symbol || actual[i].Reason != e.reason {
+			t.Errorf("expected %s: %s; got %s: %s", e.symbol, e.reason, actual[i].Symbol, actual[i].Reason)
+		}
+	}
+}
+
+func TestSource(t *testing.T) {
+	previous, latest, _ := parse(
+		[]string{"type Foo struct {", "	Bar string `json:\"bar\"`", "}"},
+		[]string{"func Foo()"},
+	)
+
+	field := previous.Decls[0].(*ast.GenDecl).Specs[0].(*ast.TypeSpec).Type.(*ast.StructType).Fields.List[0]
+	if actual := Source(field); actual != "Bar string `json:\"bar\"`" {
+		t.Errorf("expected field source; got %s", actual)
+	}
+
+	if actual := Source(latest.Decls[0]); actual != "func Foo()" {
+		t.Errorf("expected function source; got %s", actual)
+	}
+
+	if actual := Source(nil); actual != "" {
+		t.Errorf("expected no source; got %s", actual)
+	}
+}
+
 func BenchmarkCompare(b *testing.B) {
 	previous, latest, _ := parse(
 		[]string{"func Foo()"},
@@ -0,0 +1,359 @@
+package ast
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/token"
+)
+
+// constSpec is a spec of a const declaration, with the types and values
+// of its names.
+type constSpec struct {
+	types  []string
+	values []constant.Value
+}
+
+// constExpr is the expression of a constant, with the type and value of
+// iota of its spec. Specs without type and values repeat the previous ones.
+type constExpr struct {
+	typ  ast.Expr
+	expr ast.Expr
+	iota int
+}
+
+// constants evaluates the package level constants of a package, which are
+// resolved by name as constants can refer to each other.
+type constants struct {
+	exprs  map[string]constExpr
+	values map[string]constant.Value
+	specs  map[*ast.ValueSpec]*constSpec
+}
+
+func files(node ast.Node) []*ast.File {
+	switch n := node.(type) {
+	case *ast.File:
+		return []*ast.File{n}
+	case *ast.Package:
+		result := []*ast.File{}
+		for _, file := range n.Files {
+			result = append(result, file)
+		}
+		return result
+	}
+	return nil
+}
+
+func newConstants(node ast.Node) *constants {
+	c := &constants{
+		exprs:  map[string]constExpr{},
+		values: map[string]constant.Value{},
+		specs:  map[*ast.ValueSpec]*constSpec{},
+	}
+
+	specs := []*ast.ValueSpec{}
+	for _, file := range files(node) {
+		for _, decl := range file.Decls {
+			d, ok := decl.(*ast.GenDecl)
+			if !ok || d.Tok != token.CONST {
+				continue
+			}
+
+			var typ ast.Expr
+			var values []ast.Expr
+			for i, s := range d.Specs {
+				spec := s.(*ast.ValueSpec)
+
+				if spec.Type != nil || len(spec.Values) > 0 {
+					typ, values = spec.Type, spec.Values
+				}
+
+				for j, name := range spec.Names {
+					if j < len(values) {
+						c.exprs[name.Name] = constExpr{typ, values[j], i}
+					}
+				}
+
+				specs = append(specs, spec)
+			}
+		}
+	}
+
+	for _, spec := range specs {
+		s := &constSpec{}
+		for _, name := range spec.Names {
+			v := c.value(name.Name, map[string]bool{})
+			s.types = append(s.types, c.typ(name.Name, v, map[string]bool{}))
+			s.values = append(s.values, v)
+		}
+		c.specs[spec] = s
+	}
+
+	return c
+}
+
+// untypedKinds name the types of untyped constants of each kind, the way
+// the type checker names them.
+var untypedKinds = map[constant.Kind]string{
+	constant.Bool:    "untyped bool",
+	constant.String:  "untyped string",
+	constant.Int:     "untyped int",
+	constant.Float:   "untyped float",
+	constant.Complex: "untyped complex",
+}
+
+// typ returns the name of the type of a constant, or the kind of its value
+// if it is untyped. Untyped constants are told apart from typed ones, as
+// giving a constant a type, even its default one, breaks using it as a
+// value of another type.
+func (c *constants) typ(name string, v constant.Value, seen map[string]bool) string {
+	if t := c.typeOf(name, seen); t != "" {
+		return t
+	}
+	if v.Kind() == constant.Int && c.isRune(name, map[string]bool{}) {
+		return "untyped rune"
+	}
+	return untypedKinds[v.Kind()]
+}
+
+// isRune reports whether an untyped integer constant is a rune, as it has
+// a rune literal as operand.
+func (c *constants) isRune(name string, seen map[string]bool) bool {
+	e, ok := c.exprs[name]
+	if !ok || seen[name] || e.typ != nil {
+		return false
+	}
+
+	seen[name] = true
+	return c.runeExpr(e.expr, seen)
+}
+
+func (c *constants) runeExpr(expr ast.Expr, seen map[string]bool) bool {
+	switch e := expr.(type) {
+	case *ast.BasicLit:
+		return e.Kind == token.CHAR
+	case *ast.ParenExpr:
+		return c.runeExpr(e.X, seen)
+	case *ast.Ident:
+		return c.isRune(e.Name, seen)
+	case *ast.UnaryExpr:
+		return c.runeExpr(e.X, seen)
+	case *ast.BinaryExpr:
+		if e.Op == token.SHL || e.Op == token.SHR {
+			return c.runeExpr(e.X, seen)
+		}
+		return c.runeExpr(e.X, seen) || c.runeExpr(e.Y, seen)
+	}
+	return false
+}
+
+// typeOf returns the name of the type of a constant, or an empty string if
+// it is untyped.
+func (c *constants) typeOf(name string, seen map[string]bool) string {
+	e, ok := c.exprs[name]
+	if !ok || seen[name] {
+		return ""
+	}
+
+	seen[name] = true
+	if e.typ != nil {
+		return Source(e.typ)
+	}
+	return c.exprType(e.expr, seen)
+}
+
+// exprType returns the name of the type of a constant expression, which
+// is typed by its typed operands and by conversions.
+func (c *constants) exprType(expr ast.Expr, seen map[string]bool) string {
+	switch e := expr.(type) {
+	case *ast.ParenExpr:
+		return c.exprType(e.X, seen)
+	case *ast.Ident:
+		return c.typeOf(e.Name, seen)
+	case *ast.UnaryExpr:
+		return c.exprType(e.X, seen)
+	case *ast.BinaryExpr:
+		switch e.Op {
+		case token.EQL, token.NEQ, token.LSS, token.LEQ, token.GTR, token.GEQ:
+			return ""
+		case token.SHL, token.SHR:
+			return c.exprType(e.X, seen)
+		}
+		if t := c.exprType(e.X, seen); t != "" {
+			return t
+		}
+		return c.exprType(e.Y, seen)
+	case *ast.CallExpr:
+		if !builtin(e.Fun) {
+			return Source(e.Fun)
+		}
+	}
+	return ""
+}
+
+// builtin reports whether a function is a builtin, rather than a type
+// a constant is converted to.
+func builtin(fun ast.Expr) bool {
+	switch f := fun.(type) {
+	case *ast.ParenExpr:
+		return builtin(f.X)
+	case *ast.Ident:
+		switch f.Name {
+		case "len", "cap", "real", "imag", "complex", "min", "max":
+			return f.Obj == nil
+		}
+	case *ast.SelectorExpr:
+		if x, ok := f.X.(*ast.Ident); ok {
+			return x.Name == "unsafe"
+		}
+	}
+	return false
+}
+
+// value returns the value of the constant with the given name, which is
+// unknown if it can't be evaluated.
+func (c *constants) value(name string, seen map[string]bool) constant.Value {
+	if v, ok := c.values[name]; ok {
+		return v
+	}
+
+	e, ok := c.exprs[name]
+	if !ok || seen[name] {
+		return constant.MakeUnknown()
+	}
+
+	seen[name] = true
+	v := c.eval(e.expr, e.iota, seen)
+	c.values[name] = v
+	return v
+}
+
+// eval evaluates a constant expression. Conversions are evaluated as their
+// operand, as their type is told apart by exprType.
+func (c *constants) eval(expr ast.Expr, iota int, seen map[string]bool) constant.Value {
+	unknown := constant.MakeUnknown()
+
+	switch e := expr.(type) {
+	case *ast.BasicLit:
+		return constant.MakeFromLiteral(e.Value, e.Kind, 0)
+	case *ast.ParenExpr:
+		return c.eval(e.X, iota, seen)
+	case *ast.Ident:
+		switch {
+		case e.Name == "iota":
+			return constant.MakeInt64(int64(iota))
+		case e.Name == "true" && e.Obj == nil:
+			return constant.MakeBool(true)
+		case e.Name == "false" && e.Obj == nil:
+			return constant.MakeBool(false)
+		}
+		return c.value(e.Name, seen)
+	case *ast.UnaryExpr:
+		x := c.eval(e.X, iota, seen)
+		if x.Kind() == constant.Unknown {
+			return unknown
+		}
+		return constant.UnaryOp(e.Op, x, 0)
+	case *ast.BinaryExpr:
+		x, y := c.eval(e.X, iota, seen), c.eval(e.Y, iota, seen)
+		if x.Kind() == constant.Unknown || y.Kind() == constant.Unknown {
+			return unknown
+		}
+
+		switch e.Op {
+		case token.SHL, token.SHR:
+			s, ok := constant.Uint64Val(y)
+			if !ok || x.Kind() != constant.Int {
+				return unknown
+			}
+			return constant.Shift(x, e.Op, uint(s))
+		case token.EQL, token.NEQ, token.LSS, token.LEQ, token.GTR, token.GEQ:
+			return constant.MakeBool(constant.Compare(x, e.Op, y))
+		case token.QUO:
+			if constant.Sign(y) == 0 {
+				return unknown
+			}
+			// integers are divided without remainder
+			if x.Kind() == constant.Int && y.Kind() == constant.Int {
+				return constant.BinaryOp(x, token.QUO_ASSIGN, y)
+			}
+		case token.REM:
+			if constant.Sign(y) == 0 {
+				return unknown
+			}
+		}
+
+		return constant.BinaryOp(x, e.Op, y)
+	case *ast.CallExpr:
+		if len(e.Args) != 1 {
+			return unknown
+		}
+
+		x := c.eval(e.Args[0], iota, seen)
+		if id, ok := e.Fun.(*ast.Ident); ok && id.Name == "len" && builtin(id) {
+			if x.Kind() != constant.String {
+				return unknown
+			}
+			return constant.MakeInt64(int64(len(constant.StringVal(x))))
+		}
+
+		if builtin(e.Fun) {
+			return unknown
+		}
+		return x
+	}
+
+	return unknown
+}
+
+func numeric(v constant.Value) bool {
+	k := v.Kind()
+	return k == constant.Int || k == constant.Float || k == constant.Complex
+}
+
+// equalConstant reports whether two values are the same, numbers of
+// different kinds are compared by their value.
+func equalConstant(a, b constant.Value) bool {
+	if a.Kind() != b.Kind() && !(numeric(a) && numeric(b)) {
+		return false
+	}
+	return constant.Compare(a, token.EQL, b)
+}
+
+// diffConstSpec compares the type and values of two const specs. It
+// reports whether they could be evaluated, otherwise the specs are
+// compared by their expressions.
+func diffConstSpec(a, b *ast.ValueSpec, ca, cb *constSpec) (Diff, bool) {
+	var diff Diff
+
+	for _, v := range append(append([]constant.Value{}, ca.values...), cb.values...) {
+		if v.Kind() == constant.Unknown {
+			return diff, false
+		}
+	}
+
+	change := Change{
+		Type:     Major,
+		Symbol:   valueSymbol(b),
+		Previous: a,
+		Latest:   b,
+	}
+
+	for i := range ca.values {
+		p, l := ca.values[i], cb.values[i]
+
+		if ca.types[i] != cb.types[i] {
+			change.Reason = "const has changed type"
+			return diff.Add(change), true
+		}
+
+		if !equalConstant(p, l) {
+			change.Reason = "const has changed value"
+		}
+	}
+
+	if change.Reason != "" {
+		diff = diff.Add(change)
+	}
+
+	return diff, true
+}
@@ -1,11 +1,35 @@
 package ast
 
-import "go/ast"
+import (
+	"go/ast"
+	"go/token"
+)
 
 type Change struct {
 	Type             Type
 	Reason           string
+	Symbol           string
+	Package          string
 	Previous, Latest ast.Node
+
+	// Note about what still breaks with a change which is otherwise
+	// compatible
+	Note string
+
+	// Output of the tool which detected the change, like a failing test
+	Output string
+
+	// Internal marks changes to packages which can't be imported by other
+	// modules, like internal and main packages
+	Internal bool
+
+	// Suppressed is the reason a knowingly shipped change was suppressed
+	// with, suppressed changes are reported as patches
+	Suppressed string
+
+	// positions are resolved by the caller, as only it knows the file sets
+	// the nodes were parsed with
+	PreviousPosition, LatestPosition token.Position
 }
 
 type Diff []Change
@@ -69,28 +69,6 @@ func isExported(idents ...*ast.Ident) bool {
 	return false
 }
 
-func appendedFieldList(a, b *ast.FieldList) bool {
-	if a == nil && b == nil {
-		return true
-	}
-
-	if (a == nil && b != nil) || (a != nil && b == nil) {
-		return false
-	}
-
-	if len(a.List) > len(b.List) {
-		return false
-	}
-
-	for i := range a.List {
-		if !equalField(a.List[i], b.List[i]) || !equalNames(a.List[i].Names, b.List[i].Names) {
-			return false
-		}
-	}
-
-	return true
-}
-
 func equalFuncType(a, b *ast.FuncType) bool {
 	if a == nil && b == nil {
 		return true
@@ -148,12 +126,6 @@ func equalObject(a, b *ast.Object) bool {
 		return false
 	}
 
-	/*if a.Kind == ast.Con && b.Kind == ast.Con {
-		if a.Data.(int) != b.Data.(int) {
-			return false
-		}
-	}*/
-
 	return a.Kind == b.Kind && a.Name == b.Name
 }
 
@@ -22,6 +22,32 @@ func extractFuncDecl(node ast.Node) []*ast.FuncDecl {
 	return result
 }
 
+func receiverName(field *ast.Field) string {
+	expr := field.Type
+	if t, ok := expr.(*ast.StarExpr); ok {
+		expr = t.X
+	}
+
+	switch t := expr.(type) {
+	case *ast.IndexExpr:
+		expr = t.X
+	case *ast.IndexListExpr:
+		expr = t.X
+	}
+
+	if v, ok := expr.(*ast.Ident); ok {
+		return v.Name
+	}
+	return ""
+}
+
+func funcSymbol(decl *ast.FuncDecl) string {
+	if decl.Recv != nil && len(decl.Recv.List) > 0 {
+		return receiverName(decl.Recv.List[0]) + "." + decl.Name.Name
+	}
+	return decl.Name.Name
+}
+
 func diffFuncDecl(a, b *ast.FuncDecl) Diff {
 	diff := Diff{}
 
@@ -30,6 +56,7 @@ func diffFuncDecl(a, b *ast.FuncDecl) Diff {
 		return diff.Add(Change{
 			Type:   Minor,
 			Reason: "function has been added",
+			Symbol: funcSymbol(b),
 			Latest: b,
 		})
 	}
@@ -40,17 +67,7 @@ func diffFuncDecl(a, b *ast.FuncDecl) Diff {
 		if a.Recv != nil {
 			for _, field := range a.Recv.List {
 				// internal receiver, not breaking
-				var name string
-				switch t := field.Type.(type) {
-				case *ast.Ident:
-					name = t.Name
-				case *ast.StarExpr:
-					if v, ok := t.X.(*ast.Ident); ok {
-						name = v.Name
-					}
-				}
-
-				if !ast.IsExported(name) {
+				if !ast.IsExported(receiverName(field)) {
 					return diff
 				}
 			}
@@ -59,18 +76,16 @@ func diffFuncDecl(a, b *ast.FuncDecl) Diff {
 		return diff.Add(Change{
 			Type:     Major,
 			Reason:   "function has been removed",
+			Symbol:   funcSymbol(a),
 			Previous: a,
 		})
 	}
 
-	if !equalFuncType(a.Type, b.Type) {
-		a.Body, b.Body = nil, nil
-		return diff.Add(Change{
-			Type:     Major,
-			Reason:   "function signature has changed",
-			Previous: a,
-			Latest:   b,
-		})
+	a.Body, b.Body = nil, nil
+	p, l := signature(a.Type), signature(b.Type)
+	for _, change := range DiffSignature(p, l, equalSignatureExpr(p, l), widensExpr) {
+		change.Symbol, change.Previous, change.Latest = funcSymbol(b), a, b
+		diff = diff.Add(change)
 	}
 
 	return diff
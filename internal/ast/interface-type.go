@@ -0,0 +1,115 @@
+package ast
+
+import (
+	"go/ast"
+)
+
+// memberName returns the name of an interface member, embedded interfaces
+// and type constraints are named by their source.
+func memberName(field *ast.Field) string {
+	if len(field.Names) > 0 {
+		return field.Names[0].Name
+	}
+	return Source(field.Type)
+}
+
+func extractMembers(list *ast.FieldList) ([]string, map[string]*ast.Field) {
+	names := []string{}
+	members := map[string]*ast.Field{}
+
+	if list == nil {
+		return names, members
+	}
+
+	for _, field := range list.List {
+		name := memberName(field)
+		names = append(names, name)
+		members[name] = field
+	}
+
+	return names, members
+}
+
+// sealed reports whether the interface has an unexported method, which
+// makes it impossible to implement outside of its own package.
+func sealed(t *ast.InterfaceType) bool {
+	for _, field := range t.Methods.List {
+		if len(field.Names) > 0 && !isExported(field.Names...) {
+			return true
+		}
+	}
+	return false
+}
+
+func diffInterfaceType(symbol string, a, b *ast.InterfaceType) Diff {
+	var diff Diff
+	previousNames, previous := extractMembers(a.Methods)
+	latestNames, latest := extractMembers(b.Methods)
+
+	for _, name := range previousNames {
+		p := previous[name]
+		l, ok := latest[name]
+
+		if len(p.Names) > 0 && !isExported(p.Names...) {
+			// unexported methods can neither be called nor implemented
+			// outside of the package
+			continue
+		}
+
+		if !ok {
+			diff = diff.Add(Change{
+				Type:     Major,
+				Reason:   "method has been removed from interface, breaking callers",
+				Symbol:   symbol + "." + name,
+				Previous: p,
+			})
+			continue
+		}
+
+		if !equalExpr(p.Type, l.Type) {
+			diff = diff.Add(Change{
+				Type:     Major,
+				Reason:   "interface method signature has changed",
+				Symbol:   symbol + "." + name,
+				Previous: p,
+				Latest:   l,
+			})
+		}
+	}
+
+	for _, name := range latestNames {
+		if _, ok := previous[name]; ok {
+			continue
+		}
+
+		l := latest[name]
+		if len(l.Names) > 0 && !isExported(l.Names...) {
+			// an unexported method seals the interface instead
+			if !sealed(a) {
+				diff = diff.Add(Change{
+					Type:   Major,
+					Reason: "interface has been sealed by an unexported method",
+					Symbol: symbol,
+					Latest: l,
+				})
+			}
+			continue
+		}
+
+		change := Change{
+			Type:   Major,
+			Reason: "method has been added to interface, breaking implementers",
+			Symbol: symbol + "." + name,
+			Latest: l,
+		}
+
+		if sealed(a) {
+			change.Type = Minor
+			change.Reason = "method has been added to sealed interface"
+		}
+
+		diff = diff.Add(change)
+	}
+
+	return diff
+}
@@ -14,6 +14,7 @@ func comparePackage(previous, latest Node) Diff {
 			return diff.Add(Change{
 				Type:   Major,
 				Reason: fmt.Sprintf("removal of package %s", v.Name),
+				Symbol: v.Name,
 			})
 		}
 	}
@@ -23,6 +24,7 @@ func comparePackage(previous, latest Node) Diff {
 			return diff.Add(Change{
 				Type:   Minor,
 				Reason: fmt.Sprintf("addition of package %s", v.Name),
+				Symbol: v.Name,
 			})
 		}
 	}
@@ -0,0 +1,104 @@
+package ast
+
+import "strings"
+
+// kinds of objects named by the reasons of the types engine.
+var kinds = []string{"const", "var", "function", "type"}
+
+// reasons are the reasons either engine explains changes with, apart from
+// those of struct tags.
+var reasons = func() map[string]bool {
+	result := map[string]bool{}
+	for _, reason := range []string{
+		"package has been added",
+		"package has been removed",
+		"function has been added",
+		"function has been removed",
+		"value spec has been added",
+		"value spec has been removed",
+		"value spec has changed signature",
+		"const has changed type",
+		"const has changed value",
+		"var has changed type",
+		"type spec has been added",
+		"type spec has been removed",
+		"type spec has changed signature",
+		"type has changed",
+		"underlying type has changed",
+		"type parameters have changed",
+		"type parameter has been renamed",
+		"variadic parameter has been appended",
+		"variadic parameter has been introduced",
+		"variadic parameter has been removed",
+		"field has been removed",
+		"field type has changed",
+		"field has been appended",
+		"field has been added in the middle of struct",
+		"field has been added to struct with unexported fields",
+		"fields have been reordered",
+		"fields have been reordered in struct with unexported fields",
+		"struct is no longer comparable",
+		"struct can no longer be constructed with unkeyed literals",
+		"field tag has changed",
+		"method has been added",
+		"method has been removed",
+		"method has been added to interface, breaking implementers",
+		"method has been added to sealed interface",
+		"method has been removed from interface, breaking callers",
+		"interface method signature has changed",
+		"interface has been sealed by an unexported method",
+	} {
+		result[reason] = true
+	}
+
+	for _, kind := range []string{"parameter", "result"} {
+		for _, reason := range []string{
+			" has been added",
+			" has been removed",
+			" has been renamed",
+			" type has changed",
+			" has been widened to an interface",
+		} {
+			result[kind+reason] = true
+		}
+	}
+
+	for _, p := range kinds {
+		result[p+" has been added"] = true
+		result[p+" has been removed"] = true
+		for _, l := range kinds {
+			if p != l {
+				result[p+" has changed to a "+l] = true
+			}
+		}
+	}
+
+	return result
+}()
+
+// tagReasons are the reasons changes to struct tags are explained with,
+// following the key of the tag.
+var tagReasons = []string{
+	" tag name has changed, altering the encoding",
+	" tag options have changed, altering the encoding",
+	" tag has been added",
+	" tag has been removed",
+	" tag has changed",
+}
+
+// KnownReason reports whether either engine can explain a change with the
+// reason, so rules overriding the type of changes by their reason can be
+// validated.
+func KnownReason(reason string) bool {
+	if reasons[reason] {
+		return true
+	}
+
+	for _, suffix := range tagReasons {
+		if key := strings.TrimSuffix(reason, suffix); key != reason {
+			return key != "" && !strings.ContainsAny(key, " \t\":")
+		}
+	}
+
+	return false
+}
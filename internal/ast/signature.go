@@ -0,0 +1,252 @@
+package ast
+
+import (
+	"fmt"
+	"go/ast"
+	"go/scanner"
+	"go/token"
+	"strings"
+)
+
+// Param is a type parameter, parameter or result of a signature. Its type
+// is whatever the engine comparing the signatures can tell apart.
+type Param[T any] struct {
+	Name string
+	Type T
+}
+
+// Signature is a function signature as seen by either engine. The last
+// parameter of a variadic signature holds its variadic parameter, of which
+// Elem is the element type and Slice the slice the function receives.
+type Signature[T any] struct {
+	TypeParams, Params, Results []Param[T]
+	Variadic                    bool
+	Elem, Slice                 T
+}
+
+// subsequence reports whether the types of a appear in b in the same order.
+func subsequence[T any](a, b []Param[T], equal func(a, b T) bool) bool {
+	i := 0
+	for _, p := range b {
+		if i < len(a) && equal(a[i].Type, p.Type) {
+			i++
+		}
+	}
+	return i == len(a)
+}
+
+func renamed[T any](a, b []Param[T]) bool {
+	for i := range a {
+		if a[i].Name != b[i].Name {
+			return true
+		}
+	}
+	return false
+}
+
+// callCompatible notes what still breaks about changes which keep calls
+// to a function compiling.
+const callCompatible = "calls still compile, but using the function as a value of its previous type, or to implement an interface, breaks"
+
+// diffParams classifies the changes between two lists of parameters or
+// results, of which kind is either "parameter" or "result". Lists of equal
+// types differing only by name are renamed. Parameters are widened if
+// their type is replaced by one which accepts every value of it.
+func diffParams[T any](kind string, a, b []Param[T], equal, widens func(a, b T) bool) Diff {
+	diff := Diff{}
+
+	changed, widened := len(a) != len(b), false
+	for i := 0; i < len(a) && i < len(b); i++ {
+		switch {
+		case equal(a[i].Type, b[i].Type):
+		case widens != nil && widens(a[i].Type, b[i].Type):
+			widened = true
+		default:
+			changed = true
+		}
+	}
+
+	switch {
+	case !changed:
+		if widened {
+			diff = diff.Add(Change{Type: Minor, Reason: kind + " has been widened to an interface", Note: callCompatible})
+		}
+		if renamed(a, b) {
+			diff = diff.Add(Change{Type: Patch, Reason: kind + " has been renamed"})
+		}
+	case len(a) < len(b) && subsequence(a, b, equal):
+		diff = diff.Add(Change{Type: Major, Reason: kind + " has been added"})
+	case len(a) > len(b) && subsequence(b, a, equal):
+		diff = diff.Add(Change{Type: Major, Reason: kind + " has been removed"})
+	default:
+		diff = diff.Add(Change{Type: Major, Reason: kind + " type has changed"})
+		if len(a) < len(b) {
+			diff = diff.Add(Change{Type: Major, Reason: kind + " has been added"})
+		} else if len(a) > len(b) {
+			diff = diff.Add(Change{Type: Major, Reason: kind + " has been removed"})
+		}
+	}
+
+	return diff
+}
+
+// DiffSignature classifies the changes between two signatures, telling
+// what has changed about them. Equal tells whether two types are the same,
+// and widens whether a parameter of the first type can be replaced by the
+// second without breaking callers. The changes only have a type, reason
+// and note, the rest is up to the caller.
+func DiffSignature[T any](a, b Signature[T], equal, widens func(a, b T) bool) Diff {
+	diff := Diff{}
+
+	typeParams := len(a.TypeParams) != len(b.TypeParams)
+	for i := 0; i < len(a.TypeParams) && i < len(b.TypeParams); i++ {
+		if !equal(a.TypeParams[i].Type, b.TypeParams[i].Type) {
+			typeParams = true
+		}
+	}
+
+	if typeParams {
+		diff = diff.Add(Change{Type: Major, Reason: "type parameters have changed"})
+	} else if renamed(a.TypeParams, b.TypeParams) {
+		diff = diff.Add(Change{Type: Patch, Reason: "type parameter has been renamed"})
+	}
+
+	params, latest := a.Params, b.Params
+	switch {
+	case !a.Variadic && b.Variadic:
+		latest = latest[:len(latest)-1]
+		n := len(latest)
+
+		switch {
+		case len(params) == n:
+			// calls don't have to pass anything for a variadic parameter
+			// appended to the existing ones
+			diff = diff.Add(Change{Type: Minor, Reason: "variadic parameter has been appended", Note: callCompatible})
+		case len(params) == n+1 && equal(params[n].Type, b.Elem):
+			// calls passing a single value for the last parameter still
+			// compile when it turns variadic
+			diff = diff.Add(Change{Type: Minor, Reason: "variadic parameter has been introduced", Note: callCompatible})
+			params = params[:n]
+		default:
+			// a slice turned variadic is the same parameter, which has to
+			// be passed differently
+			if len(params) == n+1 && equal(params[n].Type, b.Slice) {
+				params = params[:n]
+			}
+			diff = diff.Add(Change{Type: Major, Reason: "variadic parameter has been introduced"})
+		}
+	case a.Variadic && !b.Variadic:
+		params = params[:len(params)-1]
+		n := len(params)
+
+		// the variadic parameter turned into a regular one is the same
+		// parameter, rather than one removed and another added
+		if len(latest) == n+1 && (equal(a.Elem, latest[n].Type) || equal(a.Slice, latest[n].Type)) {
+			latest = latest[:n]
+		}
+		diff = diff.Add(Change{Type: Major, Reason: "variadic parameter has been removed"})
+	}
+
+	diff = diff.Merge(diffParams("parameter", params, latest, equal, widens))
+	return diff.Merge(diffParams("result", a.Results, b.Results, equal, nil))
+}
+
+// fieldParams flattens a field list into a param per name, or a single
+// param for a field without names.
+func fieldParams(list *ast.FieldList) []Param[ast.Expr] {
+	result := []Param[ast.Expr]{}
+	if list == nil {
+		return result
+	}
+
+	for _, field := range list.List {
+		if len(field.Names) == 0 {
+			result = append(result, Param[ast.Expr]{Type: field.Type})
+		}
+
+		for _, name := range field.Names {
+			result = append(result, Param[ast.Expr]{Name: name.Name, Type: field.Type})
+		}
+	}
+
+	return result
+}
+
+func signature(t *ast.FuncType) Signature[ast.Expr] {
+	s := Signature[ast.Expr]{
+		TypeParams: fieldParams(t.TypeParams),
+		Params:     fieldParams(t.Params),
+		Results:    fieldParams(t.Results),
+	}
+
+	if n := len(s.Params); n > 0 {
+		if ellipsis, ok := s.Params[n-1].Type.(*ast.Ellipsis); ok {
+			s.Variadic = true
+			s.Elem = ellipsis.Elt
+			s.Slice = &ast.ArrayType{Elt: ellipsis.Elt}
+		}
+	}
+
+	return s
+}
+
+// normalize writes an expression with the names of type parameters
+// replaced by their position.
+func normalize(expr ast.Expr, typeParams []Param[ast.Expr]) string {
+	names := map[string]int{}
+	for i, p := range typeParams {
+		names[p.Name] = i
+	}
+
+	src := []byte(Source(expr))
+	var s scanner.Scanner
+	s.Init(token.NewFileSet().AddFile("", -1, len(src)), src, nil, 0)
+
+	var b strings.Builder
+	for {
+		_, tok, lit := s.Scan()
+		switch {
+		case tok == token.EOF:
+			return b.String()
+		case tok == token.SEMICOLON:
+			continue
+		case tok == token.IDENT:
+			if i, ok := names[lit]; ok {
+				lit = fmt.Sprintf("$%d", i)
+			}
+		case lit == "":
+			lit = tok.String()
+		}
+		b.WriteString(lit + " ")
+	}
+}
+
+// widensExpr reports whether a parameter of type a can be replaced by one
+// of type b, which is only known for the empty interface as types aren't
+// resolved.
+func widensExpr(a, b ast.Expr) bool {
+	if _, ok := a.(*ast.Ellipsis); ok {
+		return false
+	}
+
+	switch t := b.(type) {
+	case *ast.Ident:
+		return t.Name == "any" && t.Obj == nil
+	case *ast.InterfaceType:
+		return t.Methods == nil || len(t.Methods.List) == 0
+	}
+
+	return false
+}
+
+// equalSignatureExpr returns how the types of two signatures are compared,
+// which tolerates renamed type parameters.
+func equalSignatureExpr(a, b Signature[ast.Expr]) func(x, y ast.Expr) bool {
+	if len(a.TypeParams) == 0 || len(a.TypeParams) != len(b.TypeParams) {
+		return equalExpr
+	}
+
+	return func(x, y ast.Expr) bool {
+		return equalExpr(x, y) || normalize(x, a.TypeParams) == normalize(y, b.TypeParams)
+	}
+}
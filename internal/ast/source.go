@@ -0,0 +1,52 @@
+package ast
+
+import (
+	"bytes"
+	"go/ast"
+	"go/printer"
+	"go/token"
+	"strings"
+)
+
+// Source returns the printed source of node. Packages and fields are not
+// supported by go/printer, so they are printed as they would appear in a
+// declaration.
+func Source(node Node) string {
+	if node == nil {
+		return ""
+	}
+
+	if pkg, ok := node.(*ast.Package); ok {
+		return "package " + pkg.Name
+	}
+
+	if field, ok := node.(*ast.Field); ok {
+		var parts []string
+		if len(field.Names) > 0 {
+			names := []string{}
+			for _, name := range field.Names {
+				names = append(names, name.Name)
+			}
+			parts = append(parts, strings.Join(names, ", "))
+		}
+
+		if t, ok := field.Type.(*ast.FuncType); ok && len(field.Names) > 0 {
+			// interface methods are printed without the func keyword
+			return parts[0] + strings.TrimPrefix(Source(t), "func")
+		}
+
+		parts = append(parts, Source(field.Type))
+
+		if field.Tag != nil {
+			parts = append(parts, field.Tag.Value)
+		}
+
+		return strings.Join(parts, " ")
+	}
+
+	buffer := new(bytes.Buffer)
+	if err := printer.Fprint(buffer, token.NewFileSet(), node); err != nil {
+		return ""
+	}
+	return buffer.String()
+}
@@ -0,0 +1,317 @@
+package ast
+
+import (
+	"go/ast"
+	"strconv"
+)
+
+// fieldName returns the name of an embedded field, which is the name of
+// its type without pointer, package or type arguments.
+func fieldName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		return t.Name
+	case *ast.StarExpr:
+		return fieldName(t.X)
+	case *ast.SelectorExpr:
+		return t.Sel.Name
+	case *ast.IndexExpr:
+		return fieldName(t.X)
+	case *ast.IndexListExpr:
+		return fieldName(t.X)
+	}
+	return ""
+}
+
+// tag returns the unquoted tag of a field.
+func tag(field *ast.Field) string {
+	if field.Tag == nil {
+		return ""
+	}
+
+	tag, err := strconv.Unquote(field.Tag.Value)
+	if err != nil {
+		return field.Tag.Value
+	}
+	return tag
+}
+
+// exportedField reports whether a field, named or embedded, is exported.
+func exportedField(field *ast.Field) bool {
+	if len(field.Names) == 0 {
+		return ast.IsExported(fieldName(field.Type))
+	}
+	return isExported(field.Names...)
+}
+
+// extractFields returns the exported fields of a struct with a single
+// field per name, so fields declared together can be compared separately.
+func extractFields(list *ast.FieldList) ([]string, map[string]*ast.Field) {
+	names := []string{}
+	fields := map[string]*ast.Field{}
+
+	if list == nil {
+		return names, fields
+	}
+
+	for _, field := range list.List {
+		if len(field.Names) == 0 {
+			if name := fieldName(field.Type); ast.IsExported(name) {
+				names = append(names, name)
+				fields[name] = field
+			}
+			continue
+		}
+
+		for _, name := range field.Names {
+			if !ast.IsExported(name.Name) {
+				continue
+			}
+
+			names = append(names, name.Name)
+			fields[name.Name] = &ast.Field{
+				Doc:     field.Doc,
+				Names:   []*ast.Ident{name},
+				Type:    field.Type,
+				Tag:     field.Tag,
+				Comment: field.Comment,
+			}
+		}
+	}
+
+	return names, fields
+}
+
+// unexportedField returns the first unexported field of a struct, which
+// makes it impossible to construct the struct with unkeyed literals
+// outside of its package.
+func unexportedField(s *ast.StructType) *ast.Field {
+	for _, field := range s.Fields.List {
+		if !exportedField(field) {
+			return field
+		}
+	}
+	return nil
+}
+
+// comparable reports whether values of a type can be compared with ==.
+// Types declared elsewhere are assumed to be comparable, as only types of
+// the same file can be resolved.
+func comparable(expr ast.Expr, seen map[*ast.TypeSpec]bool) bool {
+	switch t := expr.(type) {
+	case *ast.ArrayType:
+		// slices have no length
+		return t.Len != nil && comparable(t.Elt, seen)
+	case *ast.MapType, *ast.FuncType:
+		return false
+	case *ast.StructType:
+		return incomparableField(t, seen) == nil
+	case *ast.ParenExpr:
+		return comparable(t.X, seen)
+	case *ast.Ident:
+		if t.Obj == nil {
+			return true
+		}
+
+		if spec, ok := t.Obj.Decl.(*ast.TypeSpec); ok && !seen[spec] {
+			seen[spec] = true
+			return comparable(spec.Type, seen)
+		}
+	}
+
+	return true
+}
+
+// incomparableField returns the first field of a struct which can't be
+// compared, making the struct itself incomparable.
+func incomparableField(s *ast.StructType, seen map[*ast.TypeSpec]bool) *ast.Field {
+	for _, field := range s.Fields.List {
+		if !comparable(field.Type, seen) {
+			return field
+		}
+	}
+	return nil
+}
+
+// Field is an exported field of a struct as seen by either engine. Its
+// type is whatever the engine comparing the structs can tell apart, and its
+// node is the declaration of the field.
+type Field[T any] struct {
+	Name string
+	Type T
+	Tag  string
+	Node Node
+}
+
+// Struct is a struct as seen by either engine.
+type Struct[T any] struct {
+	// Fields are the exported fields in the order of their declaration.
+	Fields []Field[T]
+	// Keyed structs have unexported fields, of which Unexported is the
+	// declaration of the first if it is known, so they can only be
+	// constructed with keyed literals outside of their package.
+	Keyed      bool
+	Unexported Node
+	// Comparable structs can be compared with ==, otherwise Incomparable
+	// is the declaration of the first field which can't, if it is known.
+	Comparable   bool
+	Incomparable Node
+}
+
+// DiffStruct classifies the changes between the exported fields of two
+// structs, whose symbol is the name of the struct. Equal tells whether two
+// types are the same. The changes are complete but for their package.
+func DiffStruct[T any](symbol string, a, b Struct[T], equal func(a, b T) bool) Diff {
+	var diff Diff
+
+	previous, latest := map[string]Field[T]{}, map[string]Field[T]{}
+	for _, f := range a.Fields {
+		previous[f.Name] = f
+	}
+	for _, f := range b.Fields {
+		latest[f.Name] = f
+	}
+
+	for _, p := range a.Fields {
+		l, ok := latest[p.Name]
+
+		change := Change{
+			Type:     Major,
+			Symbol:   symbol + "." + p.Name,
+			Previous: p.Node,
+			Latest:   l.Node,
+		}
+
+		switch {
+		case !ok:
+			change.Reason = "field has been removed"
+		case !equal(p.Type, l.Type):
+			change.Reason = "field type has changed"
+		default:
+			for _, c := range DiffTag(p.Name, p.Tag, l.Tag) {
+				change.Type, change.Reason = c.Type, c.Reason
+				diff = diff.Add(change)
+			}
+			continue
+		}
+
+		diff = diff.Add(change)
+	}
+
+	if a.Comparable && !b.Comparable {
+		diff = diff.Add(Change{
+			Type:   Major,
+			Reason: "struct is no longer comparable",
+			Symbol: symbol,
+			Latest: b.Incomparable,
+		})
+	}
+
+	if !a.Keyed && b.Keyed {
+		diff = diff.Add(Change{
+			Type:   Major,
+			Reason: "struct can no longer be constructed with unkeyed literals",
+			Symbol: symbol,
+			Latest: b.Unexported,
+		})
+	}
+
+	// position of the last field which is kept, fields added after it are
+	// appended rather than added in the middle of the struct
+	last := -1
+	kept := []string{}
+	for i, f := range b.Fields {
+		if _, ok := previous[f.Name]; ok {
+			last = i
+			kept = append(kept, f.Name)
+		}
+	}
+
+	order := []string{}
+	for _, f := range a.Fields {
+		if _, ok := latest[f.Name]; ok {
+			order = append(order, f.Name)
+		}
+	}
+
+	for i := range order {
+		if order[i] != kept[i] {
+			change := Change{
+				Type:     Major,
+				Reason:   "fields have been reordered",
+				Symbol:   symbol,
+				Previous: previous[order[i]].Node,
+				Latest:   latest[kept[i]].Node,
+			}
+
+			// the order of fields is free in keyed structs
+			if a.Keyed {
+				change.Type = Patch
+				change.Reason = "fields have been reordered in struct with unexported fields"
+			}
+
+			diff = diff.Add(change)
+			break
+		}
+	}
+
+	for i, f := range b.Fields {
+		if _, ok := previous[f.Name]; ok {
+			continue
+		}
+
+		// unkeyed literals break wherever a field is added, which the
+		// compatibility promise of Go allows for
+		change := Change{
+			Type:   Minor,
+			Reason: "field has been appended",
+			Symbol: symbol + "." + f.Name,
+			Latest: f.Node,
+		}
+
+		switch {
+		case a.Keyed:
+			change.Reason = "field has been added to struct with unexported fields"
+		case i < last:
+			change.Reason = "field has been added in the middle of struct"
+		}
+
+		diff = diff.Add(change)
+	}
+
+	return diff
+}
+
+// structType describes a struct by the expressions of its fields.
+func structType(s *ast.StructType) Struct[ast.Expr] {
+	result := Struct[ast.Expr]{
+		Fields: []Field[ast.Expr]{},
+	}
+
+	if field := unexportedField(s); field != nil {
+		result.Keyed, result.Unexported = true, field
+	}
+
+	names, fields := extractFields(s.Fields)
+	for _, name := range names {
+		field := fields[name]
+		result.Fields = append(result.Fields, Field[ast.Expr]{
+			Name: name,
+			Type: field.Type,
+			Tag:  tag(field),
+			Node: field,
+		})
+	}
+
+	if field := incomparableField(s, map[*ast.TypeSpec]bool{}); field != nil {
+		result.Incomparable = field
+	} else {
+		result.Comparable = true
+	}
+
+	return result
+}
+
+func diffStructType(symbol string, a, b *ast.StructType) Diff {
+	return DiffStruct(symbol, structType(a), structType(b), equalExpr)
+}
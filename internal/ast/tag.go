@@ -0,0 +1,150 @@
+package ast
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// encodings are the tag keys which decide how a field is encoded, mapped
+// to how the name of a field is derived when the tag doesn't name it.
+var encodings = map[string]func(field string) string{
+	"json":         identity,
+	"xml":          identity,
+	"toml":         identity,
+	"msgpack":      identity,
+	"mapstructure": identity,
+	"yaml":         strings.ToLower,
+	"bson":         strings.ToLower,
+	"db":           strings.ToLower,
+}
+
+func identity(s string) string {
+	return s
+}
+
+// parseTag splits a struct tag into its values by key, following the
+// conventional format of reflect.StructTag. Keys are returned in order.
+func parseTag(tag string) ([]string, map[string]string, bool) {
+	keys := []string{}
+	values := map[string]string{}
+
+	for {
+		tag = strings.TrimLeft(tag, " ")
+		if tag == "" {
+			return keys, values, true
+		}
+
+		i := 0
+		for i < len(tag) && tag[i] > ' ' && tag[i] != ':' && tag[i] != '"' && tag[i] != 0x7f {
+			i++
+		}
+
+		if i == 0 || i+1 >= len(tag) || tag[i] != ':' || tag[i+1] != '"' {
+			return nil, nil, false
+		}
+
+		key := tag[:i]
+		tag = tag[i+1:]
+
+		i = 1
+		for i < len(tag) && tag[i] != '"' {
+			if tag[i] == '\\' {
+				i++
+			}
+			i++
+		}
+
+		if i >= len(tag) {
+			return nil, nil, false
+		}
+
+		value, err := strconv.Unquote(tag[:i+1])
+		if err != nil {
+			return nil, nil, false
+		}
+		tag = tag[i+1:]
+
+		if _, ok := values[key]; !ok {
+			keys = append(keys, key)
+			values[key] = value
+		}
+	}
+}
+
+// encoding returns the name a field is encoded with and its options in a
+// stable order.
+func encoding(key, field, value string) (string, string) {
+	parts := strings.Split(value, ",")
+	name, options := parts[0], parts[1:]
+
+	if name == "" {
+		name = encodings[key](field)
+	}
+
+	sort.Strings(options)
+	return name, strings.Join(options, ",")
+}
+
+// DiffTag classifies the changes between two struct tags of a field, key by
+// key. Changes to how the field is encoded, like its name or options like
+// omitempty, are major, while other changes are patches. The changes only
+// have a type and reason, the rest is up to the caller.
+func DiffTag(field, previous, latest string) Diff {
+	diff := Diff{}
+
+	if previous == latest {
+		return diff
+	}
+
+	previousKeys, a, aok := parseTag(previous)
+	latestKeys, b, bok := parseTag(latest)
+
+	if !aok || !bok {
+		return diff.Add(Change{Type: Major, Reason: "field tag has changed"})
+	}
+
+	keys := previousKeys
+	for _, key := range latestKeys {
+		if _, ok := a[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+
+	for _, key := range keys {
+		p, pok := a[key]
+		l, lok := b[key]
+
+		if pok && lok && p == l {
+			continue
+		}
+
+		if _, ok := encodings[key]; ok {
+			pname, poptions := encoding(key, field, p)
+			lname, loptions := encoding(key, field, l)
+
+			if pname != lname {
+				diff = diff.Add(Change{Type: Major, Reason: key + " tag name has changed, altering the encoding"})
+			}
+
+			if poptions != loptions {
+				diff = diff.Add(Change{Type: Major, Reason: key + " tag options have changed, altering the encoding"})
+			}
+
+			if pname != lname || poptions != loptions {
+				continue
+			}
+		}
+
+		switch {
+		case !pok:
+			diff = diff.Add(Change{Type: Patch, Reason: key + " tag has been added"})
+		case !lok:
+			diff = diff.Add(Change{Type: Patch, Reason: key + " tag has been removed"})
+		default:
+			diff = diff.Add(Change{Type: Patch, Reason: key + " tag has changed"})
+		}
+	}
+
+	return diff
+}
@@ -41,7 +41,7 @@ func exportedTypeSpec(spec *ast.TypeSpec) *ast.TypeSpec {
 	}
 
 	for _, field := range s.Fields.List {
-		if isExported(field.Names...) {
+		if exportedField(field) {
 			t.Fields.List = append(t.Fields.List, field)
 		}
 	}
@@ -62,7 +62,8 @@ func diffTypeSpec(a, b *ast.TypeSpec) Diff {
 		return diff.Add(Change{
 			Type:   Minor,
 			Reason: "type spec has been added",
-			Latest: b,
+			Symbol: b.Name.Name,
+			Latest: exportedTypeSpec(b),
 		})
 	}
 
@@ -70,32 +71,27 @@ func diffTypeSpec(a, b *ast.TypeSpec) Diff {
 		return diff.Add(Change{
 			Type:     Major,
 			Reason:   "type spec has been removed",
-			Previous: a,
+			Symbol:   a.Name.Name,
+			Previous: exportedTypeSpec(a),
 		})
 	}
 
-	a, b, alias := aliasResolver(a, b)
+	symbol := b.Name.Name
+	a, b, _ = aliasResolver(a, b)
 
+	// structs are compared with their unexported fields, as they decide
+	// how a struct can be constructed and compared
 	if t, ok := a.Type.(*ast.StructType); ok {
-		if v, ok := b.Type.(*ast.StructType); ok {
-			if equalFieldList(t.Fields, v.Fields) {
-				// nothing changed
-				return diff
-			}
+		if v, ok := b.Type.(*ast.StructType); ok && equalFieldList(a.TypeParams, b.TypeParams) {
+			return diffStructType(symbol, t, v)
+		}
+	}
 
-			if appendedFieldList(t.Fields, v.Fields) {
-				c := *b
-				if alias {
-					// used aliased name to avoid confusion in diff
-					c.Name = a.Name
-				}
-				return diff.Add(Change{
-					Type:     Minor,
-					Reason:   "struct has appended fields",
-					Previous: a,
-					Latest:   &c,
-				})
-			}
+	a, b = exportedTypeSpec(a), exportedTypeSpec(b)
+
+	if t, ok := a.Type.(*ast.InterfaceType); ok {
+		if v, ok := b.Type.(*ast.InterfaceType); ok && equalFieldList(a.TypeParams, b.TypeParams) {
+			return diffInterfaceType(symbol, t, v)
 		}
 	}
 
@@ -103,6 +99,7 @@ func diffTypeSpec(a, b *ast.TypeSpec) Diff {
 		return diff.Add(Change{
 			Type:     Major,
 			Reason:   "type spec has changed signature",
+			Symbol:   symbol,
 			Previous: a,
 			Latest:   b,
 		})
@@ -144,10 +141,7 @@ func compareTypeSpec(a, b ast.Node) Diff {
 
 	for _, m := range match {
 		p, l := m[0], m[1]
-		diff = diff.Merge(diffTypeSpec(
-			exportedTypeSpec(p),
-			exportedTypeSpec(l),
-		))
+		diff = diff.Merge(diffTypeSpec(p, l))
 	}
 
 	return diff
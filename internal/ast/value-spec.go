@@ -2,6 +2,7 @@ package ast
 
 import (
 	"go/ast"
+	"strings"
 )
 
 func extractValueSpec(node ast.Node) []*ast.ValueSpec {
@@ -27,12 +28,21 @@ func extractValueSpec(node ast.Node) []*ast.ValueSpec {
 	return result
 }
 
-func diffValueSpec(a, b *ast.ValueSpec) Diff {
+func valueSymbol(spec *ast.ValueSpec) string {
+	names := []string{}
+	for _, name := range spec.Names {
+		names = append(names, name.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+func diffValueSpec(a, b *ast.ValueSpec, ca, cb *constants) Diff {
 	var diff Diff
 	if a == nil && b != nil {
 		return diff.Add(Change{
 			Type:   Minor,
 			Reason: "value spec has been added",
+			Symbol: valueSymbol(b),
 			Latest: b,
 		})
 	}
@@ -41,14 +51,25 @@ func diffValueSpec(a, b *ast.ValueSpec) Diff {
 		return diff.Add(Change{
 			Type:     Major,
 			Reason:   "value spec has been removed",
+			Symbol:   valueSymbol(a),
 			Previous: a,
 		})
 	}
 
-	if !equalValueSpec(a, b) {
+	p, pconst := ca.specs[a]
+	l, lconst := cb.specs[b]
+
+	if pconst && lconst {
+		if d, ok := diffConstSpec(a, b, p, l); ok {
+			return d
+		}
+	}
+
+	if pconst != lconst || !equalValueSpec(a, b) {
 		return diff.Add(Change{
 			Type:     Major,
 			Reason:   "value spec has changed signature",
+			Symbol:   valueSymbol(b),
 			Previous: a,
 			Latest:   b,
 		})
@@ -59,6 +80,7 @@ func diffValueSpec(a, b *ast.ValueSpec) Diff {
 
 func compareValueSpec(a, b ast.Node) Diff {
 	previous, latest := extractValueSpec(a), extractValueSpec(b)
+	ca, cb := newConstants(a), newConstants(b)
 	var diff Diff
 
 	match := [][2]*ast.ValueSpec{}
@@ -90,7 +112,7 @@ func compareValueSpec(a, b ast.Node) Diff {
 
 	for _, m := range match {
 		p, l := m[0], m[1]
-		diff = diff.Merge(diffValueSpec(p, l))
+		diff = diff.Merge(diffValueSpec(p, l, ca, cb))
 	}
 
 	return diff
@@ -2,20 +2,42 @@ package cache
 
 import (
 	"crypto/sha1"
+	"encoding/json"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 )
 
 const (
 	PathIsAlreadyCached    Error = "path is already cached"
 	PathIsNotCached        Error = "path is not cached"
-	CacheIsAlreadySetup    Error = "cache is already setup"
 	CacheIsNotSetup        Error = "cache is not setup"
 	FailedAtSettingUpCache Error = "failed at setting up cache"
 	RepoNotInCache         Error = "repository not in cache"
 	RefNotInCache          Error = "reference not in cache"
+	ReferenceIsLeased      Error = "reference is leased"
+)
+
+const (
+	// manifest is the name of the file in the cache directory which keeps
+	// the index of the cache between runs.
+	manifest = "manifest.json"
+	// lockfile is locked while the cache is modified, so it can be shared
+	// between processes.
+	lockfile = "lock"
+	// staging directories are where references are populated before they
+	// are saved, leftovers of crashed processes are removed after a day.
+	staging    = ".staging-"
+	stagingAge = 24 * time.Hour
+	// leaseSuffix is appended to the location of a reference to name the
+	// lease files which keep it from being evicted while it is used.
+	leaseSuffix = ".lease"
 )
 
 type Error string
@@ -24,101 +46,545 @@ func (e Error) Error() string {
 	return string(e)
 }
 
-type repo struct {
-	hash       string
-	references map[string]struct{}
+type entry struct {
+	// Checksum is the content hash of the directory of the reference.
+	Checksum string    `json:"checksum"`
+	Size     int64     `json:"size"`
+	Used     time.Time `json:"used"`
 }
 
-func (r repo) Add(reference string) {
-	r.references[reference] = struct{}{}
+type repo struct {
+	Hash       string            `json:"hash"`
+	References map[string]*entry `json:"references"`
 }
 
 func (r repo) Has(reference string) bool {
-	_, found := r.references[reference]
+	_, found := r.References[reference]
 	return found
 }
 
-var (
-	dir   string
-	cache map[string]repo
-)
+// Cache is a directory of checked out references of repositories. It is
+// safe for concurrent use, and can be shared between processes. References
+// returned by Get and Save are leased until they are released, so they are
+// not evicted while they are used by any process.
+type Cache struct {
+	mu       sync.Mutex
+	dir      string
+	repos    map[string]repo
+	verified map[string]struct{}
+	staged   map[string]struct{}
+	leases   map[string]func()
+	closed   bool
+}
+
+// Open opens the cache in path, creating it if it doesn't exist. Entries
+// of an existing cache are verified against the content hash they were
+// saved with when they are used.
+func Open(path string) (*Cache, error) {
+	entries, err := os.ReadDir(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, FailedAtSettingUpCache
+	}
+
+	if _, err := os.Stat(filepath.Join(path, manifest)); os.IsNotExist(err) && len(entries) > 0 {
+		// refuse to take over a directory which isn't a cache
+		return nil, FailedAtSettingUpCache
+	}
+
+	if err := os.MkdirAll(path, 0755); err != nil {
+		return nil, FailedAtSettingUpCache
+	}
+
+	c := &Cache{
+		dir:      path,
+		repos:    map[string]repo{},
+		verified: map[string]struct{}{},
+		staged:   map[string]struct{}{},
+		leases:   map[string]func(){},
+	}
+
+	err = c.transaction(func() error {
+		for _, r := range c.repos {
+			leftovers, _ := filepath.Glob(filepath.Join(c.dir, r.Hash, staging+"*"))
+			for _, leftover := range leftovers {
+				if info, err := os.Stat(leftover); err == nil && time.Since(info.ModTime()) > stagingAge {
+					os.RemoveAll(leftover)
+				}
+			}
+		}
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// transaction runs fn with the cache locked, both within the process and
+// on disk, and with the index of the cache loaded from the manifest. The
+// index is written back to the manifest if fn succeeds.
+func (c *Cache) transaction(fn func() error) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return CacheIsNotSetup
+	}
+
+	unlock, err := lock(filepath.Join(c.dir, lockfile))
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := c.load(); err != nil {
+		return err
+	}
+
+	if err := fn(); err != nil {
+		return err
+	}
+
+	return c.save()
+}
 
-func Setup(path string) error {
-	if cache != nil {
-		return CacheIsAlreadySetup
+// load reads the index of the cache from the manifest.
+func (c *Cache) load() error {
+	data, err := os.ReadFile(filepath.Join(c.dir, manifest))
+	if os.IsNotExist(err) {
+		c.repos = map[string]repo{}
+		return nil
 	}
 
-	dir = path
-	cache = map[string]repo{}
+	if err != nil {
+		return err
+	}
 
-	if err := os.Mkdir(dir, 0755); err != nil {
-		return FailedAtSettingUpCache
+	repos := map[string]repo{}
+	if err := json.Unmarshal(data, &repos); err != nil {
+		// the manifest of an older version, start over with an empty
+		// index. Directories are left alone, as they may be staged or
+		// leased by other processes, and references are replaced when
+		// they are saved again.
+		repos = map[string]repo{}
 	}
 
+	c.repos = repos
 	return nil
 }
 
-func Add(path, reference string) (string, error) {
-	if cache == nil {
-		return "", CacheIsNotSetup
+// save writes the index of the cache to the manifest.
+func (c *Cache) save() error {
+	data, err := json.MarshalIndent(c.repos, "", "  ")
+	if err != nil {
+		return err
 	}
 
-	h := sha1.New()
-	io.WriteString(h, path)
-	hash := fmt.Sprintf("%x", h.Sum(nil))
+	tmp := filepath.Join(c.dir, manifest+".tmp")
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, filepath.Join(c.dir, manifest))
+}
 
-	if _, ok := cache[path]; !ok {
-		cache[path] = repo{hash, map[string]struct{}{}}
-		if err := os.Mkdir(filepath.Join(dir, hash), 0755); err != nil {
-			return "", err
+// verify compares the content of a saved reference with its content hash
+// the first time it is used, and removes it from the cache if it has been
+// modified.
+func (c *Cache) verify(r repo, reference string) bool {
+	location := filepath.Join(c.dir, r.Hash, reference)
+	if _, ok := c.verified[location]; ok {
+		return true
+	}
+
+	if actual, _, err := hash(location); err != nil || actual != r.References[reference].Checksum {
+		delete(r.References, reference)
+		os.RemoveAll(location)
+		return false
+	}
+
+	c.verified[location] = struct{}{}
+	return true
+}
+
+// hash returns the content hash and size of the files in a directory.
+func hash(path string) (string, int64, error) {
+	files := []string{}
+	err := filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			files = append(files, p)
 		}
+		return nil
+	})
+
+	if err != nil {
+		return "", 0, err
 	}
 
-	if !cache[path].Has(reference) {
-		cache[path].Add(reference)
-		if err := os.Mkdir(filepath.Join(dir, hash, reference), 0755); err != nil {
-			return "", err
+	sort.Strings(files)
+
+	var size int64
+	h := sha1.New()
+	for _, file := range files {
+		rel, err := filepath.Rel(path, file)
+		if err != nil {
+			return "", 0, err
+		}
+		io.WriteString(h, filepath.ToSlash(rel))
+
+		info, err := os.Lstat(file)
+		if err != nil {
+			return "", 0, err
+		}
+		size += info.Size()
+
+		if info.Mode()&fs.ModeSymlink != 0 {
+			link, err := os.Readlink(file)
+			if err != nil {
+				return "", 0, err
+			}
+			io.WriteString(h, link)
+			continue
 		}
+
+		f, err := os.Open(file)
+		if err != nil {
+			return "", 0, err
+		}
+
+		_, err = io.Copy(h, f)
+		f.Close()
+		if err != nil {
+			return "", 0, err
+		}
+	}
+
+	return fmt.Sprintf("%x", h.Sum(nil)), size, nil
+}
+
+func repoHash(path string) string {
+	h := sha1.New()
+	io.WriteString(h, path)
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+// Add creates a staging directory for a reference to be populated in. The
+// reference is not in the cache until it has been saved.
+func (c *Cache) Add(path, reference string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return "", CacheIsNotSetup
+	}
+
+	parent := filepath.Join(c.dir, repoHash(path))
+	if err := os.MkdirAll(parent, 0755); err != nil {
+		return "", err
+	}
+
+	location, err := os.MkdirTemp(parent, staging+reference+"-")
+	if err != nil {
+		return "", err
 	}
-	return filepath.Join(dir, hash, reference), nil
+
+	c.staged[location] = struct{}{}
+	return location, nil
 }
 
-func Get(path, reference string) (string, error) {
-	repo, ok := cache[path]
+// Save moves a reference populated in the staging directory returned by Add
+// into the cache and records its content hash in the manifest, so it can be
+// reused by later runs. If the same reference was saved in the meantime,
+// the staged copy is discarded. The location of the saved reference is
+// returned.
+func (c *Cache) Save(path, reference, staged string) (string, error) {
+	var location string
+
+	c.mu.Lock()
+	_, ok := c.staged[staged]
+	c.mu.Unlock()
 
 	if !ok {
-		return "", RepoNotInCache
+		return "", RefNotInCache
 	}
 
-	if !repo.Has(reference) {
-		return "", RefNotInCache
+	// the staging directory is private to the caller, so it is hashed
+	// without holding the lock of the cache
+	checksum, size, err := hash(staged)
+	if err != nil {
+		return "", err
+	}
+
+	err = c.transaction(func() error {
+		if _, ok := c.staged[staged]; !ok {
+			return RefNotInCache
+		}
+		delete(c.staged, staged)
+
+		r, ok := c.repos[path]
+		if !ok {
+			r = repo{repoHash(path), map[string]*entry{}}
+			c.repos[path] = r
+		}
+
+		location = filepath.Join(c.dir, r.Hash, reference)
+
+		if r.Has(reference) && c.verify(r, reference) {
+			r.References[reference].Used = time.Now()
+			os.RemoveAll(staged)
+			return c.lease(location)
+		}
+
+		os.RemoveAll(location)
+		if err := os.Rename(staged, location); err != nil {
+			return err
+		}
+
+		r.References[reference] = &entry{Checksum: checksum, Size: size, Used: time.Now()}
+		c.verified[location] = struct{}{}
+		return c.lease(location)
+	})
+
+	return location, err
+}
+
+func (c *Cache) Get(path, reference string) (string, error) {
+	var location string
+
+	err := c.transaction(func() error {
+		r, ok := c.repos[path]
+
+		if !ok {
+			return RepoNotInCache
+		}
+
+		if !r.Has(reference) || !c.verify(r, reference) {
+			return RefNotInCache
+		}
+
+		r.References[reference].Used = time.Now()
+		location = filepath.Join(c.dir, r.Hash, reference)
+		return c.lease(location)
+	})
+
+	return location, err
+}
+
+// lease leases a reference until the cache is released or closed.
+func (c *Cache) lease(location string) error {
+	if _, ok := c.leases[location]; ok {
+		return nil
+	}
+
+	release, err := lease(location)
+	if err != nil {
+		return err
+	}
+
+	c.leases[location] = release
+	return nil
+}
+
+// Release releases the leases of the references returned by Get and Save,
+// which can be evicted afterwards.
+func (c *Cache) Release() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.release()
+}
+
+func (c *Cache) release() {
+	for location, release := range c.leases {
+		release()
+		delete(c.leases, location)
+	}
+}
+
+func (c *Cache) Has(path, reference string) bool {
+	found := false
+
+	c.transaction(func() error {
+		r, ok := c.repos[path]
+		found = ok && r.Has(reference) && c.verify(r, reference)
+		return nil
+	})
+
+	return found
+}
+
+// Entry describes a reference in the cache.
+type Entry struct {
+	Path      string
+	Reference string
+	Size      int64
+	Used      time.Time
+}
+
+func (c *Cache) entries() []Entry {
+	result := []Entry{}
+	for path, r := range c.repos {
+		for reference, e := range r.References {
+			result = append(result, Entry{path, reference, e.Size, e.Used})
+		}
 	}
 
-	return filepath.Join(dir, repo.hash, reference), nil
+	sort.Slice(result, func(i, j int) bool {
+		if !result[i].Used.Equal(result[j].Used) {
+			return result[i].Used.Before(result[j].Used)
+		}
+		if result[i].Path != result[j].Path {
+			return result[i].Path < result[j].Path
+		}
+		return result[i].Reference < result[j].Reference
+	})
+
+	return result
+}
+
+// List returns the references in the cache, least recently used first.
+func (c *Cache) List() ([]Entry, error) {
+	var result []Entry
+
+	err := c.transaction(func() error {
+		result = c.entries()
+		return nil
+	})
+
+	return result, err
 }
 
-func Has(path, reference string) bool {
-	repo, ok := cache[path]
+// remove evicts a reference from the cache, unless it is leased.
+func (c *Cache) remove(path, reference string) error {
+	r := c.repos[path]
+	location := filepath.Join(c.dir, r.Hash, reference)
 
+	release, ok := claim(location)
 	if !ok {
-		return false
+		return ReferenceIsLeased
+	}
+	defer release()
+
+	delete(r.References, reference)
+	delete(c.verified, location)
+
+	if err := os.RemoveAll(location); err != nil {
+		return err
+	}
+	os.Remove(location + leaseSuffix)
+
+	if len(r.References) == 0 {
+		delete(c.repos, path)
+
+		// staging directories of other processes are left alone
+		entries, _ := os.ReadDir(filepath.Join(c.dir, r.Hash))
+		for _, e := range entries {
+			if !strings.HasPrefix(e.Name(), staging) {
+				return nil
+			}
+		}
+		return os.RemoveAll(filepath.Join(c.dir, r.Hash))
 	}
 
-	return repo.Has(reference)
+	return nil
+}
+
+// Remove removes every reference of a repository from the cache, except
+// for leased references.
+func (c *Cache) Remove(path string) error {
+	return c.transaction(func() error {
+		r, ok := c.repos[path]
+		if !ok {
+			return RepoNotInCache
+		}
+
+		for reference := range r.References {
+			if err := c.remove(path, reference); err != nil && err != ReferenceIsLeased {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// Clean evicts references which haven't been used within maxAge, and the
+// least recently used references until the cache fits within maxSize
+// bytes. Limits of zero are ignored, and leased references are kept.
+func (c *Cache) Clean(maxSize int64, maxAge time.Duration) error {
+	return c.transaction(func() error {
+		entries := c.entries()
+
+		var size int64
+		for _, e := range entries {
+			size += e.Size
+		}
+
+		for _, e := range entries {
+			expired := maxAge > 0 && time.Since(e.Used) > maxAge
+			exceeded := maxSize > 0 && size > maxSize
+
+			if !expired && !exceeded {
+				continue
+			}
+
+			if err := c.remove(e.Path, e.Reference); err == ReferenceIsLeased {
+				continue
+			} else if err != nil {
+				return err
+			}
+			size -= e.Size
+		}
+
+		return nil
+	})
+}
+
+// Clear removes every reference from the cache, except for leased
+// references.
+func (c *Cache) Clear() error {
+	return c.transaction(func() error {
+		for path, r := range c.repos {
+			for reference := range r.References {
+				if err := c.remove(path, reference); err != nil && err != ReferenceIsLeased {
+					return err
+				}
+			}
+		}
+
+		return nil
+	})
 }
 
-func Clean() error {
-	if cache == nil {
+// Close discards references which were never saved, releases the leased
+// references and closes the cache, leaving its content on disk to be
+// reopened.
+func (c *Cache) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
 		return CacheIsNotSetup
 	}
+
+	for staged := range c.staged {
+		os.RemoveAll(staged)
+	}
+	c.release()
+
+	c.closed = true
 	return nil
 }
 
-func Destroy() error {
-	if cache == nil {
-		return CacheIsNotSetup
+// Destroy closes the cache and removes it from disk.
+func (c *Cache) Destroy() error {
+	if err := c.Close(); err != nil {
+		return err
 	}
 
-	cache = nil
-	return os.RemoveAll(dir)
+	return os.RemoveAll(c.dir)
 }
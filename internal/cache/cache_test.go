@@ -1,12 +1,17 @@
 package cache
 
 import (
+	"fmt"
 	"os"
+	"path/filepath"
+	"sync"
 	"testing"
+	"time"
 )
 
 func TestCache(t *testing.T) {
-	if err := Setup("testcache"); err != nil {
+	c, err := Open("testcache")
+	if err != nil {
 		t.Errorf("expected no error; got %s", err.Error())
 	}
 
@@ -14,37 +19,113 @@ func TestCache(t *testing.T) {
 		t.Error("expected testcache to exist")
 	}
 
-	if err := Setup("testcache"); err != CacheIsAlreadySetup {
-		t.Errorf("expected error of CacheIsAlreadySetup; got %s", err.Error())
+	if err := c.Destroy(); err != nil {
+		t.Errorf("expected no error; got %s", err.Error())
 	}
 
-	if err := Destroy(); err != nil {
-		t.Errorf("expected no error; got %s", err.Error())
+	if err := c.Close(); err != CacheIsNotSetup {
+		t.Errorf("expected error of CacheIsNotSetup; got %v", err)
 	}
 
 	os.Mkdir("testcache", 0755)
-	if err := Setup("testcache"); err != FailedAtSettingUpCache {
-		t.Errorf("expected error of FailedAtSettingUpCache; got %s", err.Error())
+	os.WriteFile("testcache/foo", []byte("foo"), 0644)
+	if _, err := Open("testcache"); err != FailedAtSettingUpCache {
+		t.Errorf("expected error of FailedAtSettingUpCache; got %v", err)
 	}
-	Destroy()
+	os.RemoveAll("testcache")
 }
 
-func TestAdd(t *testing.T) {
-	t.Skip() // Fix oaths
-	Setup("testcache")
-	defer Destroy()
+func TestReopen(t *testing.T) {
+	c, _ := Open("testcache")
+	defer c.Destroy()
+
+	staged, err := c.Add("./repo", "v1.0.0")
+	if err != nil {
+		t.Errorf("expected no error; got %s", err.Error())
+	}
+
+	if c.Has("./repo", "v1.0.0") {
+		t.Error("expected reference to not be cached before it is saved")
+	}
+
+	os.WriteFile(filepath.Join(staged, "foo.go"), []byte("package foo"), 0644)
 
-	expected := "testcache/8458bc5ba4df1237e49f36863f675a9ed1551c52/v1.0.0"
+	path, err := c.Save("./repo", "v1.0.0", staged)
+	if err != nil {
+		t.Errorf("expected no error; got %s", err.Error())
+	}
+
+	if err := c.Close(); err != nil {
+		t.Errorf("expected no error; got %s", err.Error())
+	}
+
+	c, err = Open("testcache")
+	if err != nil {
+		t.Errorf("expected no error; got %s", err.Error())
+	}
+
+	if !c.Has("./repo", "v1.0.0") {
+		t.Error("expected reference to be cached after reopening")
+	}
+
+	os.WriteFile(filepath.Join(path, "foo.go"), []byte("package bar"), 0644)
+	c.Close()
+	c, _ = Open("testcache")
+
+	if c.Has("./repo", "v1.0.0") {
+		t.Error("expected modified reference to be removed")
+	}
+
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("expected modified reference to be removed from disk")
+	}
+}
+
+func TestUnreadableManifest(t *testing.T) {
+	c, _ := Open("testcache")
+	defer c.Destroy()
+
+	staged, _ := c.Add("./repo", "v1.0.0")
+	os.WriteFile(filepath.Join(staged, "foo.go"), []byte("package foo"), 0644)
+	path, _ := c.Save("./repo", "v1.0.0", staged)
+	pending, _ := c.Add("./repo", "v1.1.0")
+
+	os.WriteFile(filepath.Join("testcache", manifest), []byte("["), 0644)
+
+	if c.Has("./repo", "v1.0.0") {
+		t.Error("expected reference to not be cached with an unreadable manifest")
+	}
+
+	for _, dir := range []string{path, pending} {
+		if _, err := os.Stat(dir); err != nil {
+			t.Errorf("expected %s to be left alone; got %v", dir, err)
+		}
+	}
+}
+
+func TestAdd(t *testing.T) {
+	c, _ := Open("testcache")
+	defer c.Destroy()
 
 	wd, _ := os.Getwd()
+	expected := filepath.Join("testcache", repoHash(wd), "v1.0.0")
 
-	path, err := Add(wd, "v1.0.0")
+	staged, err := c.Add(wd, "v1.0.0")
 
 	if err != nil {
 		t.Errorf("expected no error; got %s", err.Error())
 		return
 	}
 
+	if _, err := os.Stat(staged); os.IsNotExist(err) {
+		t.Error("expected staging path to exist")
+	}
+
+	path, err := c.Save(wd, "v1.0.0", staged)
+	if err != nil {
+		t.Errorf("expected no error; got %s", err.Error())
+	}
+
 	if expected != path {
 		t.Errorf("expected path of %s; got %s", expected, path)
 	}
@@ -52,19 +133,27 @@ func TestAdd(t *testing.T) {
 	if _, err := os.Stat(path); os.IsNotExist(err) {
 		t.Error("expected path to exist")
 	}
+
+	if _, err := os.Stat(staged); !os.IsNotExist(err) {
+		t.Error("expected staging path to be gone")
+	}
 }
 
 func TestGet(t *testing.T) {
-	t.Skip() // Fix oaths
-	Setup("testcache")
-	defer Destroy()
-
-	expected := "testcache/8458bc5ba4df1237e49f36863f675a9ed1551c52/v1.0.0"
+	c, _ := Open("testcache")
+	defer c.Destroy()
 
 	wd, _ := os.Getwd()
-	Add(wd, "v1.0.0")
+	expected := filepath.Join("testcache", repoHash(wd), "v1.0.0")
+
+	if _, err := c.Get(wd, "v1.0.0"); err != RepoNotInCache {
+		t.Errorf("expected error of RepoNotInCache; got %v", err)
+	}
 
-	path, err := Get(wd, "v1.0.0")
+	staged, _ := c.Add(wd, "v1.0.0")
+	c.Save(wd, "v1.0.0", staged)
+
+	path, err := c.Get(wd, "v1.0.0")
 
 	if err != nil {
 		t.Errorf("expected no error; got %s", err)
@@ -76,21 +165,161 @@ func TestGet(t *testing.T) {
 }
 
 func TestHas(t *testing.T) {
-	Setup("testcache")
-	defer Destroy()
+	c, _ := Open("testcache")
+	defer c.Destroy()
 
 	wd, _ := os.Getwd()
-	Add(wd, "v1.0.0")
+	staged, _ := c.Add(wd, "v1.0.0")
+	c.Save(wd, "v1.0.0", staged)
 
-	if !Has(wd, "v1.0.0") {
+	if !c.Has(wd, "v1.0.0") {
 		t.Error("expected cache to contain repository and version")
 	}
 
-	if Has(wd, "v1.0.1") {
+	if c.Has(wd, "v1.0.1") {
 		t.Error("expected cache to not have version")
 	}
 
-	if Has("./other-repo", "v1.0.0") {
+	if c.Has("./other-repo", "v1.0.0") {
 		t.Error("expected cache to not have repo")
 	}
 }
+
+func TestClean(t *testing.T) {
+	c, _ := Open("testcache")
+	defer c.Destroy()
+
+	for i, reference := range []string{"v1.0.0", "v1.1.0", "v1.2.0"} {
+		staged, _ := c.Add("./repo", reference)
+		os.WriteFile(filepath.Join(staged, "foo.go"), make([]byte, 100), 0644)
+		c.Save("./repo", reference, staged)
+		c.transaction(func() error {
+			c.repos["./repo"].References[reference].Used = time.Now().Add(time.Duration(i-3) * time.Hour)
+			return nil
+		})
+	}
+
+	// v1.0.0 is the least recently used, until it is used
+	c.Get("./repo", "v1.0.0")
+	c.Release()
+
+	if err := c.Clean(250, 0); err != nil {
+		t.Errorf("expected no error; got %s", err.Error())
+	}
+
+	if c.Has("./repo", "v1.1.0") || !c.Has("./repo", "v1.2.0") || !c.Has("./repo", "v1.0.0") {
+		t.Error("expected least recently used reference to be evicted")
+	}
+
+	if err := c.Clean(0, 30*time.Minute); err != nil {
+		t.Errorf("expected no error; got %s", err.Error())
+	}
+
+	entries, _ := c.List()
+	if len(entries) != 1 || entries[0].Reference != "v1.0.0" {
+		t.Errorf("expected only v1.0.0 to be left; got %v", entries)
+	}
+
+	if err := c.Remove("./repo"); err != nil {
+		t.Errorf("expected no error; got %s", err.Error())
+	}
+
+	if err := c.Remove("./repo"); err != RepoNotInCache {
+		t.Errorf("expected error of RepoNotInCache; got %v", err)
+	}
+
+	entries, _ = c.List()
+	if len(entries) != 0 {
+		t.Errorf("expected cache to be empty; got %v", entries)
+	}
+}
+
+func TestLease(t *testing.T) {
+	c, _ := Open("testcache")
+	defer c.Destroy()
+
+	// another process using the same reference
+	other, _ := Open("testcache")
+	defer other.Close()
+
+	staged, _ := c.Add("./repo", "v1.0.0")
+	os.WriteFile(filepath.Join(staged, "foo.go"), []byte("package foo"), 0644)
+	path, _ := c.Save("./repo", "v1.0.0", staged)
+	c.Release()
+
+	if _, err := other.Get("./repo", "v1.0.0"); err != nil {
+		t.Errorf("expected no error; got %s", err.Error())
+	}
+
+	if err := c.Clear(); err != nil {
+		t.Errorf("expected no error; got %s", err.Error())
+	}
+
+	if err := c.Clean(1, time.Nanosecond); err != nil {
+		t.Errorf("expected no error; got %s", err.Error())
+	}
+
+	if _, err := os.Stat(filepath.Join(path, "foo.go")); err != nil || !c.Has("./repo", "v1.0.0") {
+		t.Error("expected leased reference to be kept")
+	}
+
+	other.Release()
+
+	if err := c.Clear(); err != nil {
+		t.Errorf("expected no error; got %s", err.Error())
+	}
+
+	if _, err := os.Stat(path); !os.IsNotExist(err) || c.Has("./repo", "v1.0.0") {
+		t.Error("expected released reference to be removed")
+	}
+}
+
+func TestConcurrent(t *testing.T) {
+	shared, _ := Open("testcache")
+	defer shared.Destroy()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		// every other worker shares an instance, the rest open their own
+		// as a separate process would
+		c := shared
+		if i%2 == 1 {
+			c, _ = Open("testcache")
+			defer c.Close()
+		}
+
+		for _, reference := range []string{"v1.0.0", fmt.Sprintf("v1.%d.0", i+1)} {
+			wg.Add(1)
+			go func(c *Cache, reference string) {
+				defer wg.Done()
+
+				if c.Has("./repo", reference) {
+					return
+				}
+
+				staged, err := c.Add("./repo", reference)
+				if err != nil {
+					t.Errorf("expected no error; got %s", err.Error())
+					return
+				}
+				os.WriteFile(filepath.Join(staged, "foo.go"), []byte("package "+reference[:2]), 0644)
+
+				if _, err := c.Save("./repo", reference, staged); err != nil {
+					t.Errorf("expected no error; got %s", err.Error())
+				}
+			}(c, reference)
+		}
+	}
+	wg.Wait()
+
+	entries, _ := shared.List()
+	if len(entries) != 9 {
+		t.Errorf("expected 9 references to be cached; got %v", entries)
+	}
+
+	for _, e := range entries {
+		if !shared.Has(e.Path, e.Reference) {
+			t.Errorf("expected %s to be intact", e.Reference)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+//go:build !unix
+
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	// staleLock is the age after which a lock left behind by a crashed
+	// process is broken. Held locks are refreshed well before.
+	staleLock = time.Minute
+	// staleLease is the age after which a lease left behind by a crashed
+	// process is ignored.
+	staleLease = 24 * time.Hour
+)
+
+// lock takes an exclusive lock on path by creating it, blocking until it
+// is available. The lock is refreshed while it is held, so it is only
+// broken when its process is gone.
+func lock(path string) (func(), error) {
+	for {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			f.Close()
+			return refresh(path), nil
+		}
+
+		if !os.IsExist(err) {
+			return nil, err
+		}
+
+		if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) > staleLock {
+			os.Remove(path)
+			continue
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// refresh touches the lock in path until the returned function releases
+// it.
+func refresh(path string) func() {
+	done, stopped := make(chan struct{}), make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+
+		ticker := time.NewTicker(staleLock / 4)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case now := <-ticker.C:
+				os.Chtimes(path, now, now)
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+		os.Remove(path)
+	}
+}
+
+// lease creates a lease file next to a reference, which keeps it from
+// being evicted by any process until it is released.
+func lease(location string) (func(), error) {
+	f, err := os.CreateTemp(filepath.Dir(location), filepath.Base(location)+leaseSuffix+"-")
+	if err != nil {
+		return nil, err
+	}
+	f.Close()
+
+	return func() {
+		os.Remove(f.Name())
+	}, nil
+}
+
+// claim checks that a reference isn't leased to evict it. It is only
+// called with the cache locked, which is also when leases are taken.
+func claim(location string) (func(), bool) {
+	leases, _ := filepath.Glob(location + leaseSuffix + "-*")
+	for _, l := range leases {
+		if info, err := os.Stat(l); err == nil && time.Since(info.ModTime()) < staleLease {
+			return nil, false
+		}
+		os.Remove(l)
+	}
+
+	return func() {}, true
+}
@@ -0,0 +1,44 @@
+//go:build unix
+
+package cache
+
+import (
+	"os"
+	"syscall"
+)
+
+// lock takes an exclusive lock on path, blocking until it is available.
+// The lock is released when the process exits, even if it crashes.
+func lock(path string) (func(), error) {
+	return flock(path, syscall.LOCK_EX)
+}
+
+// lease takes a shared lock on the lease file of a reference, which keeps
+// it from being evicted by any process until it is released.
+func lease(location string) (func(), error) {
+	return flock(location+leaseSuffix, syscall.LOCK_SH)
+}
+
+// claim takes the lease file of a reference exclusively to evict it, which
+// fails if the reference is leased.
+func claim(location string) (func(), bool) {
+	release, err := flock(location+leaseSuffix, syscall.LOCK_EX|syscall.LOCK_NB)
+	return release, err == nil
+}
+
+func flock(path string, how int) (func(), error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := syscall.Flock(int(f.Fd()), how); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return func() {
+		syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		f.Close()
+	}, nil
+}
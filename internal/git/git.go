@@ -0,0 +1,148 @@
+package git
+
+import (
+	"archive/tar"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	NotARepository   Error = "not a git repository"
+	UnknownReference Error = "unknown git reference"
+)
+
+type Error string
+
+func (e Error) Error() string {
+	return string(e)
+}
+
+func run(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+
+	stdout, stderr := new(bytes.Buffer), new(bytes.Buffer)
+	cmd.Stdout, cmd.Stderr = stdout, stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("git %s: %s", strings.Join(args, " "), strings.TrimSpace(stderr.String()))
+	}
+
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// Root returns the top level directory of the repository containing dir.
+func Root(dir string) (string, error) {
+	root, err := run(dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", NotARepository
+	}
+	return root, nil
+}
+
+// Resolve returns the full commit hash the reference points to.
+func Resolve(dir, reference string) (string, error) {
+	commit, err := run(dir, "rev-parse", "--verify", "--quiet", reference+"^{commit}")
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", UnknownReference, reference)
+	}
+	return commit, nil
+}
+
+// Extract writes the tree of commit into dst without touching the
+// working tree or index of the repository.
+func Extract(dir, commit, dst string) error {
+	cmd := exec.Command("git", "archive", "--format=tar", commit)
+	cmd.Dir = dir
+
+	stderr := new(bytes.Buffer)
+	cmd.Stderr = stderr
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	if err := untar(stdout, dst); err != nil {
+		cmd.Wait()
+		return err
+	}
+
+	if err := cmd.Wait(); err != nil {
+		return fmt.Errorf("git archive %s: %s", commit, strings.TrimSpace(stderr.String()))
+	}
+
+	return nil
+}
+
+func untar(r io.Reader, dst string) error {
+	tr := tar.NewReader(r)
+
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		path := filepath.Join(dst, filepath.FromSlash(header.Name))
+		if !strings.HasPrefix(path, filepath.Clean(dst)+string(filepath.Separator)) {
+			return fmt.Errorf("invalid path in archive: %s", header.Name)
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(path, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				return err
+			}
+
+			f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(header.Mode)&0777)
+			if err != nil {
+				return err
+			}
+
+			if _, err := io.Copy(f, tr); err != nil {
+				f.Close()
+				return err
+			}
+
+			if err := f.Close(); err != nil {
+				return err
+			}
+		case tar.TypeSymlink:
+			if err := os.Symlink(header.Linkname, path); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// Tags returns the tags reachable from HEAD.
+func Tags(dir string) ([]string, error) {
+	out, err := run(dir, "tag", "--merged", "HEAD")
+	if err != nil {
+		return nil, err
+	}
+
+	if out == "" {
+		return nil, nil
+	}
+
+	return strings.Split(out, "\n"), nil
+}
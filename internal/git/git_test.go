@@ -0,0 +1,79 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func setup(t *testing.T) string {
+	dir := t.TempDir()
+
+	commands := [][]string{
+		{"init", "-q"},
+		{"add", "-A"},
+		{"-c", "user.name=semver", "-c", "user.email=semver@example.com", "commit", "-q", "-m", "initial"},
+		{"tag", "v1.0.0"},
+	}
+
+	os.WriteFile(filepath.Join(dir, "foo.go"), []byte("package foo\n"), 0644)
+
+	for _, args := range commands {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Skipf("git is not available: %s", out)
+		}
+	}
+
+	return dir
+}
+
+func TestResolve(t *testing.T) {
+	dir := setup(t)
+
+	head, err := Resolve(dir, "HEAD")
+	if err != nil {
+		t.Errorf("expected no error; got %s", err)
+	}
+
+	tag, err := Resolve(dir, "v1.0.0")
+	if err != nil {
+		t.Errorf("expected no error; got %s", err)
+	}
+
+	if head != tag || len(head) != 40 {
+		t.Errorf("expected tag to resolve to HEAD; got %s and %s", tag, head)
+	}
+
+	if _, err := Resolve(dir, "v2.0.0"); err == nil {
+		t.Error("expected error for unknown reference")
+	}
+}
+
+func TestExtract(t *testing.T) {
+	dir := setup(t)
+	dst := t.TempDir()
+
+	if err := Extract(dir, "v1.0.0", dst); err != nil {
+		t.Errorf("expected no error; got %s", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dst, "foo.go")); os.IsNotExist(err) {
+		t.Error("expected foo.go to be extracted")
+	}
+}
+
+func TestTags(t *testing.T) {
+	dir := setup(t)
+
+	tags, err := Tags(dir)
+	if err != nil {
+		t.Errorf("expected no error; got %s", err)
+	}
+
+	if len(tags) != 1 || tags[0] != "v1.0.0" {
+		t.Errorf("expected tags [v1.0.0]; got %v", tags)
+	}
+}
@@ -0,0 +1,9 @@
+//go:build go1.22
+
+package types
+
+import "go/types"
+
+func unalias(t types.Type) types.Type {
+	return types.Unalias(t)
+}
@@ -0,0 +1,11 @@
+//go:build !go1.22
+
+package types
+
+import "go/types"
+
+// aliases are never materialized before go1.22, they always denote the
+// aliased type directly.
+func unalias(t types.Type) types.Type {
+	return t
+}
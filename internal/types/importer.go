@@ -0,0 +1,199 @@
+package types
+
+import (
+	"fmt"
+	goast "go/ast"
+	"go/build"
+	goimporter "go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ImportError reports an import which could not be resolved. The types
+// depending on it are invalid, and would compare equal between versions.
+type ImportError struct {
+	Path string
+	Pos  token.Pos
+	Err  error
+}
+
+func (e *ImportError) Error() string {
+	return fmt.Sprintf("could not import %s: %s", e.Path, e.Err)
+}
+
+func (e *ImportError) Unwrap() error {
+	return e.Err
+}
+
+// position sets the position of an import error to the first import of
+// the package in files.
+func (e *ImportError) position(files []*goast.File) {
+	for _, file := range files {
+		for _, spec := range file.Imports {
+			if path, err := strconv.Unquote(spec.Path.Value); err == nil && path == e.Path {
+				e.Pos = spec.Pos()
+				return
+			}
+		}
+	}
+}
+
+// importer type checks the packages of the modules of a version from the
+// tree of the version, and any other package from source.
+type importer struct {
+	mu       sync.Mutex
+	modules  map[string]string
+	fset     *token.FileSet
+	packages map[string]*imported
+	fallback types.ImporterFrom
+}
+
+// imported is a package of a module which has been type checked.
+type imported struct {
+	pkg *types.Package
+	err error
+}
+
+// NewImporter returns an importer type checking imports from source.
+// Modules maps the path of every module of a version to its directory, so
+// their packages are imported from the tree of the version rather than
+// resolved by the go tool. The importer should be reused for every package
+// of a version, as it caches the packages it has imported, and is safe for
+// concurrent use.
+func NewImporter(modules map[string]string) types.Importer {
+	fset := token.NewFileSet()
+	return &importer{
+		modules:  modules,
+		fset:     fset,
+		packages: map[string]*imported{},
+		fallback: goimporter.ForCompiler(fset, "source", nil).(types.ImporterFrom),
+	}
+}
+
+func (i *importer) Import(path string) (*types.Package, error) {
+	return i.ImportFrom(path, "", 0)
+}
+
+// ImportFrom serializes imports, as the source importer is not safe for
+// concurrent use.
+func (i *importer) ImportFrom(path, dir string, mode types.ImportMode) (*types.Package, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.load(path, dir)
+}
+
+// dir returns the directory of a package of one of the modules, the most
+// specific module wins when modules are nested.
+func (i *importer) dir(path string) (string, bool) {
+	module := ""
+	for m := range i.modules {
+		if (path == m || strings.HasPrefix(path, m+"/")) && len(m) > len(module) {
+			module = m
+		}
+	}
+
+	if module == "" {
+		return "", false
+	}
+
+	rel := strings.TrimPrefix(strings.TrimPrefix(path, module), "/")
+	return filepath.Join(i.modules[module], filepath.FromSlash(rel)), true
+}
+
+func (i *importer) load(path, dir string) (*types.Package, error) {
+	local, ok := i.dir(path)
+	if !ok {
+		return i.fallback.ImportFrom(path, dir, 0)
+	}
+
+	if p, ok := i.packages[path]; ok {
+		if p == nil {
+			return nil, fmt.Errorf("import cycle through %s", path)
+		}
+		return p.pkg, p.err
+	}
+
+	// a package being type checked is nil, to catch import cycles
+	i.packages[path] = nil
+	pkg, err := i.check(path, local)
+	i.packages[path] = &imported{pkg, err}
+
+	return pkg, err
+}
+
+// check type checks a package of a module, with the files the go tool
+// would build it from.
+func (i *importer) check(path, dir string) (*types.Package, error) {
+	bp, err := build.Default.ImportDir(dir, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	files := []*goast.File{}
+	for _, name := range append(bp.GoFiles, bp.CgoFiles...) {
+		file, err := parser.ParseFile(i.fset, filepath.Join(dir, name), nil, 0)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+	}
+
+	imp := &recorder{imp: (*unlocked)(i)}
+	conf := types.Config{
+		Importer:    imp,
+		FakeImportC: true,
+		Error:       func(error) {},
+	}
+
+	pkg, _ := conf.Check(path, i.fset, files, nil)
+	if imp.err != nil {
+		return nil, imp.err
+	}
+
+	return pkg, nil
+}
+
+// unlocked imports the dependencies of a package of a module, which is type
+// checked while the importer is locked.
+type unlocked importer
+
+func (u *unlocked) Import(path string) (*types.Package, error) {
+	return u.ImportFrom(path, "", 0)
+}
+
+func (u *unlocked) ImportFrom(path, dir string, mode types.ImportMode) (*types.Package, error) {
+	return (*importer)(u).load(path, dir)
+}
+
+// recorder records the first import which could not be resolved while type
+// checking a package.
+type recorder struct {
+	imp types.Importer
+	err *ImportError
+}
+
+func (r *recorder) Import(path string) (*types.Package, error) {
+	return r.ImportFrom(path, "", 0)
+}
+
+func (r *recorder) ImportFrom(path, dir string, mode types.ImportMode) (*types.Package, error) {
+	var pkg *types.Package
+	var err error
+
+	if from, ok := r.imp.(types.ImporterFrom); ok {
+		pkg, err = from.ImportFrom(path, dir, mode)
+	} else {
+		pkg, err = r.imp.Import(path)
+	}
+
+	if err != nil && r.err == nil {
+		r.err = &ImportError{Path: path, Err: err}
+	}
+
+	return pkg, err
+}
@@ -0,0 +1,527 @@
+package types
+
+import (
+	"fmt"
+	goast "go/ast"
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+
+	"github.com/quartercastle/semver/internal/ast"
+)
+
+// Package is a type checked package together with the declarations of its
+// objects, so changes can point at the source they originate from.
+type Package struct {
+	*types.Package
+	decls map[token.Pos]ast.Node
+}
+
+// Check type checks a parsed package. Type errors are tolerated and leave
+// the affected types invalid, but an import which can't be resolved is
+// returned as an ImportError, as it would hide any change of the types
+// depending on it.
+func Check(path string, fset *token.FileSet, pkg *goast.Package, imp types.Importer) (*Package, error) {
+	if pkg == nil {
+		return nil, nil
+	}
+
+	filenames := []string{}
+	for filename := range pkg.Files {
+		filenames = append(filenames, filename)
+	}
+	sort.Strings(filenames)
+
+	files := []*goast.File{}
+	for _, filename := range filenames {
+		files = append(files, pkg.Files[filename])
+	}
+
+	rec := &recorder{imp: imp}
+	conf := types.Config{
+		Importer:    rec,
+		FakeImportC: true,
+		Error:       func(error) {},
+	}
+
+	checked, _ := conf.Check(path, fset, files, nil)
+	if rec.err != nil {
+		rec.err.position(files)
+		return nil, rec.err
+	}
+
+	return &Package{checked, declarations(files)}, nil
+}
+
+func declarations(files []*goast.File) map[token.Pos]ast.Node {
+	decls := map[token.Pos]ast.Node{}
+
+	for _, file := range files {
+		for _, decl := range file.Decls {
+			switch d := decl.(type) {
+			case *goast.FuncDecl:
+				// the body is not part of the api
+				c := *d
+				c.Body = nil
+				decls[d.Name.Pos()] = &c
+			case *goast.GenDecl:
+				for _, spec := range d.Specs {
+					switch s := spec.(type) {
+					case *goast.TypeSpec:
+						decls[s.Name.Pos()] = s
+						members(decls, s.Type)
+					case *goast.ValueSpec:
+						for _, name := range s.Names {
+							decls[name.Pos()] = s
+						}
+					}
+				}
+			}
+		}
+	}
+
+	return decls
+}
+
+// members adds the fields of structs and the methods of interfaces to decls.
+func members(decls map[token.Pos]ast.Node, expr goast.Expr) {
+	var list *goast.FieldList
+	switch t := expr.(type) {
+	case *goast.StructType:
+		list = t.Fields
+	case *goast.InterfaceType:
+		list = t.Methods
+	default:
+		return
+	}
+
+	for _, field := range list.List {
+		if len(field.Names) == 0 {
+			// embedded fields are positioned at their type
+			pos := field.Type.Pos()
+			if star, ok := field.Type.(*goast.StarExpr); ok {
+				pos = star.X.Pos()
+			}
+			if selector, ok := field.Type.(*goast.SelectorExpr); ok {
+				pos = selector.Sel.Pos()
+			}
+			decls[pos] = field
+		}
+		for _, name := range field.Names {
+			decls[name.Pos()] = field
+		}
+	}
+}
+
+func (p *Package) node(obj types.Object) ast.Node {
+	if p == nil || obj == nil {
+		return nil
+	}
+	return p.decls[obj.Pos()]
+}
+
+// qualifier prints objects with their full package path, so types of the
+// same name from different packages are distinguished.
+func qualifier(p *types.Package) string {
+	return p.Path()
+}
+
+func exported(p *Package) map[string]types.Object {
+	objects := map[string]types.Object{}
+
+	if p == nil || p.Package == nil {
+		return objects
+	}
+
+	scope := p.Scope()
+	for _, name := range scope.Names() {
+		if obj := scope.Lookup(name); obj.Exported() {
+			objects[name] = obj
+		}
+	}
+
+	return objects
+}
+
+func kind(obj types.Object) string {
+	switch obj.(type) {
+	case *types.Const:
+		return "const"
+	case *types.Var:
+		return "var"
+	case *types.Func:
+		return "function"
+	case *types.TypeName:
+		return "type"
+	}
+	return "object"
+}
+
+// Compare compares the exported api of two type checked versions of a
+// package. Types are compared by their fully qualified type strings, so
+// aliases are resolved and named types from different packages never match.
+func Compare(previous, latest *Package) ast.Diff {
+	var diff ast.Diff
+	a, b := exported(previous), exported(latest)
+
+	for _, name := range names(a, b) {
+		p, l := a[name], b[name]
+
+		switch {
+		case p == nil:
+			diff = diff.Add(ast.Change{
+				Type:   ast.Minor,
+				Reason: fmt.Sprintf("%s has been added", kind(l)),
+				Symbol: name,
+				Latest: latest.node(l),
+			})
+		case l == nil:
+			diff = diff.Add(ast.Change{
+				Type:     ast.Major,
+				Reason:   fmt.Sprintf("%s has been removed", kind(p)),
+				Symbol:   name,
+				Previous: previous.node(p),
+			})
+		case kind(p) != kind(l):
+			diff = diff.Add(ast.Change{
+				Type:     ast.Major,
+				Reason:   fmt.Sprintf("%s has changed to a %s", kind(p), kind(l)),
+				Symbol:   name,
+				Previous: previous.node(p),
+				Latest:   latest.node(l),
+			})
+		default:
+			diff = diff.Merge(compareObject(previous, latest, p, l))
+		}
+	}
+
+	return diff
+}
+
+func compareObject(previous, latest *Package, p, l types.Object) ast.Diff {
+	var diff ast.Diff
+	change := ast.Change{
+		Symbol:   p.Name(),
+		Previous: previous.node(p),
+		Latest:   latest.node(l),
+	}
+
+	switch t := p.(type) {
+	case *types.Const:
+		v := l.(*types.Const)
+		if typeString(t.Type()) != typeString(v.Type()) {
+			change.Type, change.Reason = ast.Major, "const has changed type"
+			return diff.Add(change)
+		}
+		if t.Val().ExactString() != v.Val().ExactString() {
+			change.Type, change.Reason = ast.Major, "const has changed value"
+			return diff.Add(change)
+		}
+	case *types.Var:
+		if typeString(p.Type()) != typeString(l.Type()) {
+			change.Type, change.Reason = ast.Major, "var has changed type"
+			return diff.Add(change)
+		}
+	case *types.Func:
+		return compareSignature(change, p.Type(), l.Type())
+	case *types.TypeName:
+		return compareTypeName(previous, latest, t, l.(*types.TypeName))
+	}
+
+	return diff
+}
+
+// signature describes a signature by the types of its parameters.
+func signature(s *types.Signature) ast.Signature[types.Type] {
+	result := ast.Signature[types.Type]{
+		TypeParams: []ast.Param[types.Type]{},
+		Params:     tuple(s.Params()),
+		Results:    tuple(s.Results()),
+		Variadic:   s.Variadic(),
+	}
+
+	if s.Variadic() {
+		result.Slice = s.Params().At(s.Params().Len() - 1).Type()
+		result.Elem = result.Slice.(*types.Slice).Elem()
+	}
+
+	for i := 0; i < s.TypeParams().Len(); i++ {
+		t := s.TypeParams().At(i)
+		result.TypeParams = append(result.TypeParams, ast.Param[types.Type]{
+			Name: t.Obj().Name(),
+			Type: t.Constraint(),
+		})
+	}
+
+	return result
+}
+
+func tuple(t *types.Tuple) []ast.Param[types.Type] {
+	result := []ast.Param[types.Type]{}
+	for i := 0; i < t.Len(); i++ {
+		result = append(result, ast.Param[types.Type]{
+			Name: t.At(i).Name(),
+			Type: t.At(i).Type(),
+		})
+	}
+	return result
+}
+
+func identical(a, b types.Type) bool {
+	return typeString(a) == typeString(b)
+}
+
+// widens reports whether a parameter of type a can be replaced by an
+// interface b which a implements. The versions are type checked apart, so
+// only interfaces of methods with predeclared types are known to match.
+func widens(a, b types.Type) bool {
+	if _, ok := b.(*types.TypeParam); ok {
+		return false
+	}
+
+	i, ok := b.Underlying().(*types.Interface)
+	return ok && types.Implements(a, i)
+}
+
+// compareSignature classifies the changes between the signatures of two
+// functions or methods, filling in the rest of the changes from change.
+func compareSignature(change ast.Change, p, l types.Type) ast.Diff {
+	var diff ast.Diff
+
+	for _, c := range ast.DiffSignature(signature(p.(*types.Signature)), signature(l.(*types.Signature)), identical, widens) {
+		change.Type, change.Reason, change.Note = c.Type, c.Reason, c.Note
+		diff = diff.Add(change)
+	}
+
+	return diff
+}
+
+func compareTypeName(previous, latest *Package, p, l *types.TypeName) ast.Diff {
+	var diff ast.Diff
+	change := ast.Change{
+		Symbol:   p.Name(),
+		Previous: previous.node(p),
+		Latest:   latest.node(l),
+	}
+
+	pn, pok := p.Type().(*types.Named)
+	ln, lok := l.Type().(*types.Named)
+
+	// aliases and unnamed types are identical if they denote the same type
+	if p.IsAlias() || l.IsAlias() || !pok || !lok {
+		if typeString(p.Type()) != typeString(l.Type()) {
+			change.Type, change.Reason = ast.Major, "type has changed"
+			return diff.Add(change)
+		}
+		return diff
+	}
+
+	if typeParams(pn.TypeParams()) != typeParams(ln.TypeParams()) {
+		change.Type, change.Reason = ast.Major, "type parameters have changed"
+		return diff.Add(change)
+	}
+
+	pu, lu := pn.Underlying(), ln.Underlying()
+	ps, psok := pu.(*types.Struct)
+	ls, lsok := lu.(*types.Struct)
+	pi, piok := pu.(*types.Interface)
+	li, liok := lu.(*types.Interface)
+
+	switch {
+	case psok && lsok:
+		diff = diff.Merge(ast.DiffStruct(p.Name(), structType(previous, ps), structType(latest, ls), identical))
+	case piok && liok && embeddedTerms(pi) == embeddedTerms(li):
+		// interfaces are compared even if they print the same, as
+		// methods of embedded interfaces are not printed
+		diff = diff.Merge(compareInterface(previous, latest, p.Name(), pi, li))
+	case typeString(pu) != typeString(lu):
+		change.Type, change.Reason = ast.Major, "underlying type has changed"
+		diff = diff.Add(change)
+	}
+
+	return diff.Merge(compareMethods(previous, latest, pn, ln))
+}
+
+func typeParams(list *types.TypeParamList) string {
+	params := []string{}
+	for i := 0; i < list.Len(); i++ {
+		params = append(params, typeString(list.At(i).Constraint()))
+	}
+	return strings.Join(params, ", ")
+}
+
+// structType describes a struct by the types of its exported fields.
+func structType(pkg *Package, s *types.Struct) ast.Struct[types.Type] {
+	result := ast.Struct[types.Type]{
+		Fields:     []ast.Field[types.Type]{},
+		Comparable: types.Comparable(s),
+	}
+
+	for i := 0; i < s.NumFields(); i++ {
+		f := s.Field(i)
+		if !f.Exported() {
+			// structs with unexported fields can only be constructed
+			// with keyed literals outside of their package
+			if !result.Keyed {
+				result.Keyed, result.Unexported = true, pkg.node(f)
+			}
+		} else {
+			result.Fields = append(result.Fields, ast.Field[types.Type]{
+				Name: f.Name(),
+				Type: f.Type(),
+				Tag:  s.Tag(i),
+				Node: pkg.node(f),
+			})
+		}
+
+		if result.Incomparable == nil && !types.Comparable(f.Type()) {
+			result.Incomparable = pkg.node(f)
+		}
+	}
+
+	return result
+}
+
+// methods returns the exported methods in the method set of *T, which
+// includes the methods of T.
+func methods(t *types.Named) map[string]*types.Func {
+	result := map[string]*types.Func{}
+
+	if _, ok := t.Underlying().(*types.Interface); ok {
+		// interface methods are part of the underlying type
+		return result
+	}
+
+	set := types.NewMethodSet(types.NewPointer(t))
+	for i := 0; i < set.Len(); i++ {
+		if f, ok := set.At(i).Obj().(*types.Func); ok && f.Exported() {
+			result[f.Name()] = f
+		}
+	}
+
+	return result
+}
+
+func compareMethods(previous, latest *Package, p, l *types.Named) ast.Diff {
+	var diff ast.Diff
+	a, b := methods(p), methods(l)
+
+	for _, name := range names(a, b) {
+		pm, lm := a[name], b[name]
+		symbol := p.Obj().Name() + "." + name
+
+		switch {
+		case pm == nil:
+			diff = diff.Add(ast.Change{
+				Type:   ast.Minor,
+				Reason: "method has been added",
+				Symbol: symbol,
+				Latest: latest.node(lm),
+			})
+		case lm == nil:
+			diff = diff.Add(ast.Change{
+				Type:     ast.Major,
+				Reason:   "method has been removed",
+				Symbol:   symbol,
+				Previous: previous.node(pm),
+			})
+		default:
+			diff = diff.Merge(compareSignature(ast.Change{
+				Symbol:   symbol,
+				Previous: previous.node(pm),
+				Latest:   latest.node(lm),
+			}, pm.Type(), lm.Type()))
+		}
+	}
+
+	return diff
+}
+
+// embeddedTerms returns the type terms of a constraint interface, which
+// are not part of its method set.
+func embeddedTerms(t *types.Interface) string {
+	terms := []string{}
+	for i := 0; i < t.NumEmbeddeds(); i++ {
+		if _, ok := t.EmbeddedType(i).(*types.Union); ok {
+			terms = append(terms, typeString(t.EmbeddedType(i)))
+		}
+	}
+	return strings.Join(terms, "; ")
+}
+
+func interfaceMethods(t *types.Interface) (map[string]*types.Func, bool) {
+	result := map[string]*types.Func{}
+	sealed := false
+
+	for i := 0; i < t.NumMethods(); i++ {
+		m := t.Method(i)
+		if !m.Exported() {
+			sealed = true
+			continue
+		}
+		result[m.Name()] = m
+	}
+
+	return result, sealed
+}
+
+// compareInterface compares the complete method sets of two interfaces.
+// Adding a method breaks implementers, unless the interface is sealed by
+// an unexported method, and removing one breaks callers.
+func compareInterface(previous, latest *Package, name string, p, l *types.Interface) ast.Diff {
+	var diff ast.Diff
+	a, sealed := interfaceMethods(p)
+	b, seal := interfaceMethods(l)
+
+	if seal && !sealed {
+		diff = diff.Add(ast.Change{
+			Type:   ast.Major,
+			Reason: "interface has been sealed by an unexported method",
+			Symbol: name,
+		})
+	}
+
+	for _, method := range names(a, b) {
+		pm, lm := a[method], b[method]
+		change := ast.Change{Symbol: name + "." + method}
+
+		if pm != nil {
+			change.Previous = previous.node(pm)
+		}
+		if lm != nil {
+			change.Latest = latest.node(lm)
+		}
+
+		switch {
+		case pm == nil && sealed:
+			change.Type, change.Reason = ast.Minor, "method has been added to sealed interface"
+		case pm == nil:
+			change.Type, change.Reason = ast.Major, "method has been added to interface, breaking implementers"
+		case lm == nil:
+			change.Type, change.Reason = ast.Major, "method has been removed from interface, breaking callers"
+		case typeString(pm.Type()) != typeString(lm.Type()):
+			change.Type, change.Reason = ast.Major, "interface method signature has changed"
+		default:
+			continue
+		}
+
+		diff = diff.Add(change)
+	}
+
+	return diff
+}
+
+func names[T any](a, b map[string]T) []string {
+	result := []string{}
+	for name := range a {
+		result = append(result, name)
+	}
+	for name := range b {
+		if _, ok := a[name]; !ok {
+			result = append(result, name)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
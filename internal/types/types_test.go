@@ -0,0 +1,444 @@
+package types
+
+import (
+	goast "go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+
+	"github.com/quartercastle/semver/internal/ast"
+)
+
+var imp = NewImporter(nil)
+
+func check(t *testing.T, src []string) *Package {
+	fset := token.NewFileSet()
+	src = append([]string{"package foo"}, src...)
+
+	file, err := parser.ParseFile(fset, "foo.go", strings.Join(src, "\n"), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pkg, err := Check("example.com/foo", fset, &goast.Package{
+		Name:  "foo",
+		Files: map[string]*goast.File{"foo.go": file},
+	}, imp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return pkg
+}
+
+func TestCompare(t *testing.T) {
+	tc := []struct {
+		title            string
+		previous, latest []string
+		expected         ast.Type
+	}{
+		{
+			"No difference",
+			[]string{"func Foo()"},
+			[]string{"func Foo()"},
+			ast.Patch,
+		},
+		{
+			"addition of exported function",
+			[]string{"func Foo()"},
+			[]string{"func Foo()", "func Bar()"},
+			ast.Minor,
+		},
+		{
+			"removal of exported function",
+			[]string{"func Foo()", "func Bar()"},
+			[]string{"func Foo()"},
+			ast.Major,
+		},
+		{
+			"removal of internal function",
+			[]string{"func Foo()", "func bar()"},
+			[]string{"func Foo()"},
+			ast.Patch,
+		},
+		{
+			"renamed argument",
+			[]string{"func Foo(a string)"},
+			[]string{"func Foo(b string)"},
+			ast.Patch,
+		},
+		{
+			"renamed type parameter",
+			[]string{"func Foo[T any](T)"},
+			[]string{"func Foo[K any](K)"},
+			ast.Patch,
+		},
+		{
+			"same type name from different packages",
+			[]string{`import "io"`, "func Foo(io.Reader)"},
+			[]string{`import "bufio"`, "func Foo(bufio.Reader)"},
+			ast.Major,
+		},
+		{
+			"argument replaced by alias of same type",
+			[]string{`import "io"`, "type R = io.Reader", "func Foo(io.Reader)"},
+			[]string{`import "io"`, "type R = io.Reader", "func Foo(R)"},
+			ast.Patch,
+		},
+		{
+			"alias of different type",
+			[]string{`import "io"`, "type R = io.Reader"},
+			[]string{`import "io"`, "type R = io.Writer"},
+			ast.Major,
+		},
+		{
+			"defined type replaced by alias",
+			[]string{"type Foo int"},
+			[]string{"type Foo = int"},
+			ast.Major,
+		},
+		{
+			"struct with appended field",
+			[]string{"type Foo struct { A int }"},
+			[]string{"type Foo struct { A int; B int }"},
+			ast.Minor,
+		},
+		{
+			"addition of internal field",
+			[]string{"type Foo struct { A int }"},
+			[]string{"type Foo struct { a int; A int }"},
+			ast.Major,
+		},
+		{
+			"struct with changed field type",
+			[]string{"type Foo struct { A int; B int }"},
+			[]string{"type Foo struct { A int; B string }"},
+			ast.Major,
+		},
+		{
+			"insertion of internal field in struct with only exported fields",
+			[]string{"type Foo struct { A, B int }"},
+			[]string{"type Foo struct { A int; c int; B int }"},
+			ast.Major,
+		},
+		{
+			"insertion of field in struct",
+			[]string{"type Foo struct { A int; B int }"},
+			[]string{"type Foo struct { A int; C int; B int }"},
+			ast.Minor,
+		},
+		{
+			"change of field tag",
+			[]string{"type Foo struct { A int `json:\"a\"` }"},
+			[]string{"type Foo struct { A int `json:\"b\"` }"},
+			ast.Major,
+		},
+		{
+			"addition of method",
+			[]string{"type Foo struct{}"},
+			[]string{"type Foo struct{}", "func (Foo) Bar() {}"},
+			ast.Minor,
+		},
+		{
+			"removal of pointer method",
+			[]string{"type Foo struct{}", "func (*Foo) Bar() {}"},
+			[]string{"type Foo struct{}"},
+			ast.Major,
+		},
+		{
+			"addition of method to interface",
+			[]string{"type Foo interface { Bar() }"},
+			[]string{"type Foo interface { Bar(); Baz() }"},
+			ast.Major,
+		},
+		{
+			"addition of method to sealed interface",
+			[]string{"type Foo interface { Bar(); bar() }"},
+			[]string{"type Foo interface { Bar(); Baz(); bar() }"},
+			ast.Minor,
+		},
+		{
+			"addition of method through embedded interface",
+			[]string{`import "io"`, "type Foo interface { io.Reader }"},
+			[]string{`import "io"`, "type Foo interface { io.ReadCloser }"},
+			ast.Major,
+		},
+		{
+			"addition of method to embedded interface",
+			[]string{"type bar interface { Bar() }", "type Foo interface { Foo(); bar }"},
+			[]string{"type bar interface { Bar(); Baz() }", "type Foo interface { Foo(); bar }"},
+			ast.Major,
+		},
+		{
+			"embedding an interface with the same methods",
+			[]string{"type Foo interface { Read([]byte) (int, error) }"},
+			[]string{`import "io"`, "type Foo interface { io.Reader }"},
+			ast.Patch,
+		},
+		{
+			"change of const type",
+			[]string{"const Foo int = 1"},
+			[]string{"const Foo int64 = 1"},
+			ast.Major,
+		},
+		{
+			"const changed to var",
+			[]string{"const Foo = 1"},
+			[]string{"var Foo = 1"},
+			ast.Major,
+		},
+		{
+			"reordering fields of struct with unexported field",
+			[]string{"type Foo struct {", "	Foo int", "	Bar int", "	baz int", "}"},
+			[]string{"type Foo struct {", "	Bar int", "	Foo int", "	baz int", "}"},
+			ast.Patch,
+		},
+		{
+			"addition of field in the middle of struct with unexported field",
+			[]string{"type Foo struct {", "	Foo int", "	Bar int", "	baz int", "}"},
+			[]string{"type Foo struct {", "	Foo int", "	Qux int", "	Bar int", "	baz int", "}"},
+			ast.Minor,
+		},
+		{
+			"removal of field from struct with unexported field",
+			[]string{"type Foo struct {", "	Foo int", "	Bar int", "	baz int", "}"},
+			[]string{"type Foo struct {", "	Foo int", "	baz int", "}"},
+			ast.Major,
+		},
+		{
+			"appending slice field to comparable struct",
+			[]string{"type Foo struct {", "	Foo int", "}"},
+			[]string{"type Foo struct {", "	Foo int", "	Bar []int", "}"},
+			ast.Major,
+		},
+		{
+			"appending unexported func field to comparable struct",
+			[]string{"type Foo struct {", "	Foo int", "	bar int", "}"},
+			[]string{"type Foo struct {", "	Foo int", "	bar int", "	baz func()", "}"},
+			ast.Major,
+		},
+		{
+			"appending field of incomparable named type to comparable struct",
+			[]string{"type List []int", "type Foo struct {", "	Foo int", "}"},
+			[]string{"type List []int", "type Foo struct {", "	Foo int", "	Bar List", "}"},
+			ast.Major,
+		},
+		{
+			"appending map field to incomparable struct",
+			[]string{"type Foo struct {", "	Foo []int", "}"},
+			[]string{"type Foo struct {", "	Foo []int", "	Bar map[string]int", "}"},
+			ast.Minor,
+		},
+		{
+			"addition of omitempty to json tag",
+			[]string{"type Foo struct {", "	Foo int `json:\"foo\"`", "}"},
+			[]string{"type Foo struct {", "	Foo int `json:\"foo,omitempty\"`", "}"},
+			ast.Major,
+		},
+		{
+			"addition of unrelated tag key",
+			[]string{"type Foo struct {", "	Foo int `json:\"foo\"`", "}"},
+			[]string{"type Foo struct {", "	Foo int `json:\"foo\" validate:\"required\"`", "}"},
+			ast.Patch,
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.title, func(t *testing.T) {
+			actual := Compare(check(t, c.previous), check(t, c.latest))
+
+			for _, change := range actual {
+				if !ast.KnownReason(change.Reason) {
+					t.Errorf("expected reason %q to be known", change.Reason)
+				}
+			}
+
+			if actual.Type() != c.expected {
+				var reason string
+				if len(actual) > 0 {
+					reason = actual[len(actual)-1].Reason
+				}
+				t.Errorf(
+					"expected difference of %s; got %s\nreason: %s",
+					c.expected, actual.Type(), reason,
+				)
+			}
+		})
+	}
+}
+
+func TestSignature(t *testing.T) {
+	tc := []struct {
+		title            string
+		previous, latest []string
+		expected         []string
+	}{
+		{
+			"renamed parameter",
+			[]string{"func Foo(a int) {}"},
+			[]string{"func Foo(b int) {}"},
+			[]string{"parameter has been renamed"},
+		},
+		{
+			"renamed result",
+			[]string{"func Foo() (n int) { return }"},
+			[]string{"func Foo() (m int) { return }"},
+			[]string{"result has been renamed"},
+		},
+		{
+			"renamed type parameter",
+			[]string{"func Foo[T any, S ~[]T](s S) T { var t T; return t }"},
+			[]string{"func Foo[E any, S ~[]E](s S) E { var e E; return e }"},
+			[]string{"type parameter has been renamed"},
+		},
+		{
+			"changed type parameters",
+			[]string{"func Foo[T any](T) {}"},
+			[]string{"func Foo[T comparable](T) {}"},
+			[]string{"type parameters have changed"},
+		},
+		{
+			"added parameter",
+			[]string{"func Foo(a int) {}"},
+			[]string{"func Foo(s string, a int) {}"},
+			[]string{"parameter has been added"},
+		},
+		{
+			"removed parameter",
+			[]string{"func Foo(a, b int) {}"},
+			[]string{"func Foo(a int) {}"},
+			[]string{"parameter has been removed"},
+		},
+		{
+			"changed parameter type",
+			[]string{"func Foo(a int) {}"},
+			[]string{"func Foo(a string) {}"},
+			[]string{"parameter type has changed"},
+		},
+		{
+			"changed parameter type and added parameter",
+			[]string{"func Foo(a int) {}"},
+			[]string{"func Foo(a string, b bool) {}"},
+			[]string{"parameter type has changed", "parameter has been added"},
+		},
+		{
+			"added result",
+			[]string{"func Foo() {}"},
+			[]string{"func Foo() error { return nil }"},
+			[]string{"result has been added"},
+		},
+		{
+			"removed result",
+			[]string{"func Foo() (int, error) { return 0, nil }"},
+			[]string{"func Foo() int { return 0 }"},
+			[]string{"result has been removed"},
+		},
+		{
+			"changed result type",
+			[]string{"func Foo() int { return 0 }"},
+			[]string{"func Foo() string { return \"\" }"},
+			[]string{"result type has changed"},
+		},
+		{
+			"introduced variadic parameter",
+			[]string{"func Foo(a []int) {}"},
+			[]string{"func Foo(a ...int) {}"},
+			[]string{"variadic parameter has been introduced"},
+		},
+		{
+			"last parameter turned variadic",
+			[]string{"func Foo(s string, a int) {}"},
+			[]string{"func Foo(s string, a ...int) {}"},
+			[]string{"variadic parameter has been introduced"},
+		},
+		{
+			"variadic parameter turned into a slice",
+			[]string{"func Foo(a ...int) {}"},
+			[]string{"func Foo(a []int) {}"},
+			[]string{"variadic parameter has been removed"},
+		},
+		{
+			"variadic parameter turned into its element type",
+			[]string{"func Foo(a ...int) {}"},
+			[]string{"func Foo(a int) {}"},
+			[]string{"variadic parameter has been removed"},
+		},
+		{
+			"removed variadic parameter",
+			[]string{"func Foo(a int, b ...int) {}"},
+			[]string{"func Foo(a int) {}"},
+			[]string{"variadic parameter has been removed"},
+		},
+		{
+			"appended variadic parameter",
+			[]string{"type Option func()", "func Foo(a int) {}"},
+			[]string{"type Option func()", "func Foo(a int, opts ...Option) {}"},
+			[]string{"variadic parameter has been appended"},
+		},
+		{
+			"appended variadic parameter and changed parameter type",
+			[]string{"type Option func()", "func Foo(a int) {}"},
+			[]string{"type Option func()", "func Foo(a string, opts ...Option) {}"},
+			[]string{"variadic parameter has been appended", "parameter type has changed"},
+		},
+		{
+			"widened parameter to the empty interface",
+			[]string{"func Foo(a int, b string) {}"},
+			[]string{"func Foo(a any, b interface{}) {}"},
+			[]string{"parameter has been widened to an interface"},
+		},
+		{
+			"narrowed parameter from the empty interface",
+			[]string{"func Foo(a any) {}"},
+			[]string{"func Foo(a int) {}"},
+			[]string{"parameter type has changed"},
+		},
+		{
+			"widened variadic parameter",
+			[]string{"func Foo(a ...int) {}"},
+			[]string{"func Foo(a ...any) {}"},
+			[]string{"parameter type has changed"},
+		},
+		{
+			"result changed to the empty interface",
+			[]string{"func Foo() int { return 0 }"},
+			[]string{"func Foo() any { return 0 }"},
+			[]string{"result type has changed"},
+		},
+		{
+			"widened parameter to an implemented interface",
+			[]string{"import \"bytes\"", "func Foo(b *bytes.Buffer) {}"},
+			[]string{"import \"io\"", "func Foo(w io.Writer) {}"},
+			[]string{"parameter has been widened to an interface", "parameter has been renamed"},
+		},
+		{
+			"changed parameter to an interface which is not implemented",
+			[]string{"func Foo(a int) {}"},
+			[]string{"import \"io\"", "func Foo(a io.Writer) {}"},
+			[]string{"parameter type has changed"},
+		},
+		{
+			"changed method parameter type",
+			[]string{"type Bar struct{}", "func (Bar) Foo(a int) {}"},
+			[]string{"type Bar struct{}", "func (Bar) Foo(a string) {}"},
+			[]string{"parameter type has changed"},
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.title, func(t *testing.T) {
+			reasons := []string{}
+			for _, change := range Compare(check(t, c.previous), check(t, c.latest)) {
+				if !ast.KnownReason(change.Reason) {
+					t.Errorf("expected reason %q to be known", change.Reason)
+				}
+				reasons = append(reasons, change.Reason)
+			}
+
+			if strings.Join(reasons, ", ") != strings.Join(c.expected, ", ") {
+				t.Errorf("expected %v; got %v", c.expected, reasons)
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package types
+
+import (
+	"bytes"
+	"fmt"
+	"go/types"
+	"strconv"
+)
+
+// typeString prints t with the full package path of every named type and
+// every alias resolved, so two types print the same if they are identical.
+// Type parameters are printed by index, as renaming them is not a change.
+func typeString(t types.Type) string {
+	buffer := new(bytes.Buffer)
+	writeType(buffer, t)
+	return buffer.String()
+}
+
+func writeType(buffer *bytes.Buffer, t types.Type) {
+	switch t := unalias(t).(type) {
+	case nil:
+		buffer.WriteString("<nil>")
+	case *types.Basic:
+		buffer.WriteString(t.Name())
+	case *types.Pointer:
+		buffer.WriteString("*")
+		writeType(buffer, t.Elem())
+	case *types.Slice:
+		buffer.WriteString("[]")
+		writeType(buffer, t.Elem())
+	case *types.Array:
+		fmt.Fprintf(buffer, "[%d]", t.Len())
+		writeType(buffer, t.Elem())
+	case *types.Map:
+		buffer.WriteString("map[")
+		writeType(buffer, t.Key())
+		buffer.WriteString("]")
+		writeType(buffer, t.Elem())
+	case *types.Chan:
+		switch t.Dir() {
+		case types.SendRecv:
+			buffer.WriteString("chan ")
+		case types.SendOnly:
+			buffer.WriteString("chan<- ")
+		case types.RecvOnly:
+			buffer.WriteString("<-chan ")
+		}
+		writeType(buffer, t.Elem())
+	case *types.Signature:
+		buffer.WriteString("func")
+		writeSignature(buffer, t)
+	case *types.Struct:
+		buffer.WriteString("struct{")
+		for i := 0; i < t.NumFields(); i++ {
+			if i > 0 {
+				buffer.WriteString("; ")
+			}
+			f := t.Field(i)
+			if !f.Embedded() {
+				buffer.WriteString(f.Name() + " ")
+			}
+			writeType(buffer, f.Type())
+			if tag := t.Tag(i); tag != "" {
+				buffer.WriteString(" " + strconv.Quote(tag))
+			}
+		}
+		buffer.WriteString("}")
+	case *types.Interface:
+		buffer.WriteString("interface{")
+		first := true
+		for i := 0; i < t.NumEmbeddeds(); i++ {
+			if !first {
+				buffer.WriteString("; ")
+			}
+			writeType(buffer, t.EmbeddedType(i))
+			first = false
+		}
+		for i := 0; i < t.NumExplicitMethods(); i++ {
+			if !first {
+				buffer.WriteString("; ")
+			}
+			m := t.ExplicitMethod(i)
+			buffer.WriteString(m.Name())
+			writeSignature(buffer, m.Type().(*types.Signature))
+			first = false
+		}
+		buffer.WriteString("}")
+	case *types.Union:
+		for i := 0; i < t.Len(); i++ {
+			if i > 0 {
+				buffer.WriteString(" | ")
+			}
+			if t.Term(i).Tilde() {
+				buffer.WriteString("~")
+			}
+			writeType(buffer, t.Term(i).Type())
+		}
+	case *types.Named:
+		if pkg := t.Obj().Pkg(); pkg != nil {
+			buffer.WriteString(qualifier(pkg) + ".")
+		}
+		buffer.WriteString(t.Obj().Name())
+		if args := t.TypeArgs(); args.Len() > 0 {
+			buffer.WriteString("[")
+			for i := 0; i < args.Len(); i++ {
+				if i > 0 {
+					buffer.WriteString(", ")
+				}
+				writeType(buffer, args.At(i))
+			}
+			buffer.WriteString("]")
+		}
+	case *types.TypeParam:
+		fmt.Fprintf(buffer, "$%d", t.Index())
+	case *types.Tuple:
+		writeTuple(buffer, t, false)
+	default:
+		buffer.WriteString(t.String())
+	}
+}
+
+func writeTuple(buffer *bytes.Buffer, t *types.Tuple, variadic bool) {
+	buffer.WriteString("(")
+	for i := 0; i < t.Len(); i++ {
+		if i > 0 {
+			buffer.WriteString(", ")
+		}
+		v := t.At(i).Type()
+		if variadic && i == t.Len()-1 {
+			if s, ok := v.(*types.Slice); ok {
+				buffer.WriteString("...")
+				v = s.Elem()
+			}
+		}
+		writeType(buffer, v)
+	}
+	buffer.WriteString(")")
+}
+
+func writeSignature(buffer *bytes.Buffer, sig *types.Signature) {
+	if params := sig.TypeParams(); params.Len() > 0 {
+		buffer.WriteString("[")
+		for i := 0; i < params.Len(); i++ {
+			if i > 0 {
+				buffer.WriteString(", ")
+			}
+			writeType(buffer, params.At(i).Constraint())
+		}
+		buffer.WriteString("]")
+	}
+
+	writeTuple(buffer, sig.Params(), sig.Variadic())
+
+	if results := sig.Results(); results.Len() > 0 {
+		buffer.WriteString(" ")
+		writeTuple(buffer, results, false)
+	}
+}
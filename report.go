@@ -0,0 +1,125 @@
+package semver
+
+import (
+	"go/token"
+	"path/filepath"
+	"sort"
+
+	"github.com/quartercastle/semver/internal/ast"
+)
+
+// Report is the result of comparing two versions.
+type Report struct {
+	// Type is the type of the most significant change between the
+	// versions, regardless of which changes are included in the report.
+	Type Type `json:"type"`
+	// Changes are ordered by package, file, position and symbol.
+	Changes []Change `json:"changes"`
+	// Warnings about the comparison, like suppressions which no longer
+	// match any change.
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// Change is a single change to the api between two versions.
+type Change struct {
+	Type     Type         `json:"type"`
+	Reason   string       `json:"reason"`
+	Symbol   string       `json:"symbol"`
+	Package  string       `json:"package"`
+	Previous *Declaration `json:"previous,omitempty"`
+	Latest   *Declaration `json:"latest,omitempty"`
+	// Note about what still breaks with a change which is otherwise
+	// compatible.
+	Note string `json:"note,omitempty"`
+	// Output of the tool which detected the change, like a failing test.
+	Output string `json:"output,omitempty"`
+	// Internal is set for changes to internal and main packages, which
+	// are not part of the api and therefore reported as patches.
+	Internal bool `json:"internal,omitempty"`
+	// Suppressed is the reason of the suppression which acknowledged the
+	// change, suppressed changes are reported as patches.
+	Suppressed string `json:"suppressed,omitempty"`
+}
+
+// Declaration is the source of a changed symbol in one of the versions.
+type Declaration struct {
+	File   string `json:"file"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+	Source string `json:"source,omitempty"`
+}
+
+func newDeclaration(node ast.Node, position token.Position) *Declaration {
+	if node == nil && !position.IsValid() {
+		return nil
+	}
+
+	return &Declaration{
+		File:   position.Filename,
+		Line:   position.Line,
+		Column: position.Column,
+		Source: ast.Source(node),
+	}
+}
+
+func newChange(c ast.Change) Change {
+	return Change{
+		Type:       c.Type,
+		Reason:     c.Reason,
+		Symbol:     c.Symbol,
+		Package:    c.Package,
+		Previous:   newDeclaration(c.Previous, c.PreviousPosition),
+		Latest:     newDeclaration(c.Latest, c.LatestPosition),
+		Note:       c.Note,
+		Output:     c.Output,
+		Internal:   c.Internal,
+		Suppressed: c.Suppressed,
+	}
+}
+
+// position returns where a change is located, in the latest version unless
+// the change is a removal. Only the name of the file is used, as the
+// versions are in different directories.
+func (c Change) position() (string, int, int) {
+	d := c.Latest
+	if d == nil {
+		d = c.Previous
+	}
+
+	if d == nil {
+		return "", 0, 0
+	}
+
+	return filepath.Base(d.File), d.Line, d.Column
+}
+
+// sort orders the changes by package, file, position and symbol, so the
+// report is the same between runs.
+func (r *Report) sort() {
+	sort.SliceStable(r.Changes, func(i, j int) bool {
+		a, b := r.Changes[i], r.Changes[j]
+		if a.Package != b.Package {
+			return a.Package < b.Package
+		}
+
+		af, al, ac := a.position()
+		bf, bl, bc := b.position()
+		if af != bf {
+			return af < bf
+		}
+		if al != bl {
+			return al < bl
+		}
+		if ac != bc {
+			return ac < bc
+		}
+
+		if a.Symbol != b.Symbol {
+			return a.Symbol < b.Symbol
+		}
+		if a.Type != b.Type {
+			return a.Type > b.Type
+		}
+		return a.Reason < b.Reason
+	})
+}
@@ -0,0 +1,103 @@
+package semver
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/quartercastle/semver/internal/ast"
+)
+
+// Suppression acknowledges changes which are shipped knowingly, like a
+// breaking change to an experimental package. Matching changes are
+// reported as patches.
+type Suppression struct {
+	// Symbol of the changes, like Foo or T.Method. Every symbol of the
+	// package is matched if empty.
+	Symbol string `json:"symbol,omitempty"`
+	// Package is the import path of the changes, sub packages are matched
+	// too if it ends with /... . Every package is matched if empty.
+	Package string `json:"package,omitempty"`
+	// Reason the changes are acknowledged.
+	Reason string `json:"reason"`
+	// Allow is the most significant type of change which is suppressed,
+	// more significant changes are still reported.
+	Allow Type `json:"allow"`
+}
+
+func (s Suppression) String() string {
+	switch {
+	case s.Package == "":
+		return s.Symbol
+	case s.Symbol == "":
+		return s.Package
+	}
+	return s.Package + "." + s.Symbol
+}
+
+func (s Suppression) validate() error {
+	if s.Symbol == "" && s.Package == "" {
+		return fmt.Errorf("%w: either symbol or package is required", InvalidSuppression)
+	}
+
+	if s.Reason == "" {
+		return fmt.Errorf("%w: %s has no reason", InvalidSuppression, s)
+	}
+
+	if s.Allow != Minor && s.Allow != Major {
+		return fmt.Errorf("%w: %s must allow either minor or major changes", InvalidSuppression, s)
+	}
+
+	return nil
+}
+
+func (s Suppression) matches(change ast.Change) bool {
+	if s.Symbol != "" && s.Symbol != change.Symbol {
+		return false
+	}
+
+	if pkg := strings.TrimSuffix(s.Package, "/..."); pkg != s.Package {
+		return change.Package == pkg || strings.HasPrefix(change.Package, pkg+"/")
+	}
+
+	return s.Package == "" || s.Package == change.Package
+}
+
+// suppress reports changes acknowledged by a suppression as patches. It
+// returns warnings about suppressions which are stale as they no longer
+// match any change, and about changes exceeding what their suppression
+// allows.
+func suppress(diff ast.Diff, suppressions []Suppression) []string {
+	var warnings []string
+	used := make([]bool, len(suppressions))
+
+	for i, change := range diff {
+		for j, s := range suppressions {
+			if !s.matches(change) {
+				continue
+			}
+
+			used[j] = true
+			if change.Type > s.Allow {
+				warnings = append(warnings, fmt.Sprintf(
+					"suppression of %s allows %s changes, %s has a %s change",
+					s, s.Allow, change.Symbol, change.Type,
+				))
+				continue
+			}
+
+			diff[i].Type = ast.Patch
+			diff[i].Suppressed = s.Reason
+			break
+		}
+	}
+
+	for j, s := range suppressions {
+		if !used[j] {
+			warnings = append(warnings, fmt.Sprintf("suppression of %s is stale, it matches no change", s))
+		}
+	}
+
+	sort.Strings(warnings)
+	return warnings
+}
@@ -0,0 +1,246 @@
+package semver
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/quartercastle/semver/internal/ast"
+)
+
+// Type is the kind of change between two versions.
+type Type = ast.Type
+
+const (
+	Patch   = ast.Patch
+	Minor   = ast.Minor
+	Major   = ast.Major
+	Unknown = ast.Unknown
+)
+
+// ParseType parses the name of a type of change, like major, regardless
+// of case.
+func ParseType(s string) (Type, error) {
+	for _, t := range []Type{Patch, Minor, Major, Unknown} {
+		if strings.EqualFold(t.String(), s) {
+			return t, nil
+		}
+	}
+	return Patch, fmt.Errorf("%w: %q", InvalidType, s)
+}
+
+// Version is a semantic version as described by https://semver.org/spec/v2.0.0.html.
+// The Go convention of prefixing versions with a v is supported and kept
+// when the version is printed.
+type Version struct {
+	Prefix              bool
+	Major, Minor, Patch uint64
+	Prerelease          []string
+	Build               []string
+}
+
+// Parse parses and validates a semantic version like v1.2.3-rc.1+build.5.
+func Parse(s string) (Version, error) {
+	var v Version
+	invalid := fmt.Errorf("%w: %q", InvalidVersion, s)
+
+	if strings.HasPrefix(s, "v") {
+		v.Prefix, s = true, s[1:]
+	}
+
+	if i := strings.IndexByte(s, '+'); i >= 0 {
+		v.Build, s = strings.Split(s[i+1:], "."), s[:i]
+		for _, identifier := range v.Build {
+			if !isIdentifier(identifier) {
+				return Version{}, invalid
+			}
+		}
+	}
+
+	if i := strings.IndexByte(s, '-'); i >= 0 {
+		v.Prerelease, s = strings.Split(s[i+1:], "."), s[:i]
+		for _, identifier := range v.Prerelease {
+			if !isIdentifier(identifier) || (isNumeric(identifier) && !isNumber(identifier)) {
+				return Version{}, invalid
+			}
+		}
+	}
+
+	core := strings.Split(s, ".")
+	if len(core) != 3 {
+		return Version{}, invalid
+	}
+
+	for i, n := range []*uint64{&v.Major, &v.Minor, &v.Patch} {
+		if !isNumber(core[i]) {
+			return Version{}, invalid
+		}
+
+		number, err := strconv.ParseUint(core[i], 10, 64)
+		if err != nil {
+			return Version{}, invalid
+		}
+		*n = number
+	}
+
+	return v, nil
+}
+
+// MustParse is like Parse but panics if the version is invalid.
+func MustParse(s string) Version {
+	v, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+func (v Version) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+
+	if v.Prefix {
+		s = "v" + s
+	}
+
+	if len(v.Prerelease) > 0 {
+		s += "-" + strings.Join(v.Prerelease, ".")
+	}
+
+	if len(v.Build) > 0 {
+		s += "+" + strings.Join(v.Build, ".")
+	}
+
+	return s
+}
+
+// Compare returns -1, 0 or 1 depending on whether v has lower, equal or
+// higher precedence than q. Build metadata does not affect precedence.
+func (v Version) Compare(q Version) int {
+	for _, pair := range [][2]uint64{{v.Major, q.Major}, {v.Minor, q.Minor}, {v.Patch, q.Patch}} {
+		if pair[0] != pair[1] {
+			if pair[0] < pair[1] {
+				return -1
+			}
+			return 1
+		}
+	}
+
+	// a pre-release version has lower precedence than the release
+	switch {
+	case len(v.Prerelease) == 0 && len(q.Prerelease) == 0:
+		return 0
+	case len(v.Prerelease) == 0:
+		return 1
+	case len(q.Prerelease) == 0:
+		return -1
+	}
+
+	for i := 0; i < len(v.Prerelease) && i < len(q.Prerelease); i++ {
+		if c := compareIdentifier(v.Prerelease[i], q.Prerelease[i]); c != 0 {
+			return c
+		}
+	}
+
+	switch {
+	case len(v.Prerelease) < len(q.Prerelease):
+		return -1
+	case len(v.Prerelease) > len(q.Prerelease):
+		return 1
+	}
+
+	return 0
+}
+
+// Less reports whether v has lower precedence than q.
+func (v Version) Less(q Version) bool {
+	return v.Compare(q) < 0
+}
+
+// Bump returns the version following v for a change of type t, unknown
+// changes are treated as breaking. Bumping a pre-release version releases
+// it, if the change fits within it.
+func (v Version) Bump(t Type) Version {
+	next := Version{Prefix: v.Prefix, Major: v.Major, Minor: v.Minor, Patch: v.Patch}
+	prerelease := len(v.Prerelease) > 0
+
+	switch {
+	case t >= Major:
+		if !prerelease || v.Minor != 0 || v.Patch != 0 {
+			next.Major, next.Minor, next.Patch = v.Major+1, 0, 0
+		}
+	case t == Minor:
+		if !prerelease || v.Patch != 0 {
+			next.Minor, next.Patch = v.Minor+1, 0
+		}
+	default:
+		if !prerelease {
+			next.Patch = v.Patch + 1
+		}
+	}
+
+	return next
+}
+
+// Sort sorts versions in increasing order of precedence.
+func Sort(versions []Version) {
+	sort.SliceStable(versions, func(i, j int) bool {
+		return versions[i].Less(versions[j])
+	})
+}
+
+func compareIdentifier(a, b string) int {
+	an, bn := isNumeric(a), isNumeric(b)
+
+	switch {
+	case an && bn:
+		// numbers without leading zeroes compare by length first
+		switch {
+		case len(a) < len(b):
+			return -1
+		case len(a) > len(b):
+			return 1
+		}
+		return strings.Compare(a, b)
+	case an:
+		// numeric identifiers have lower precedence than alphanumeric
+		return -1
+	case bn:
+		return 1
+	}
+
+	return strings.Compare(a, b)
+}
+
+func isIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+
+	for _, r := range s {
+		if !(r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r == '-') {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isNumeric(s string) bool {
+	if s == "" {
+		return false
+	}
+
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// isNumber reports whether s is numeric without leading zeroes.
+func isNumber(s string) bool {
+	return isNumeric(s) && (s == "0" || s[0] != '0')
+}
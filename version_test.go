@@ -0,0 +1,142 @@
+package semver
+
+import (
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tc := []struct {
+		version string
+		valid   bool
+	}{
+		{"1.2.3", true},
+		{"v1.2.3", true},
+		{"v0.0.0", true},
+		{"1.0.0-alpha", true},
+		{"1.0.0-alpha.1", true},
+		{"1.0.0-0.3.7", true},
+		{"1.0.0-x.7.z.92", true},
+		{"1.0.0-x-y-z.--", true},
+		{"1.0.0+20130313144700", true},
+		{"1.0.0-beta+exp.sha.5114f85", true},
+		{"1.0.0+21AF26D3----117B344092BD", true},
+		{"1", false},
+		{"1.2", false},
+		{"1.2.3.4", false},
+		{"01.2.3", false},
+		{"1.02.3", false},
+		{"1.2.03", false},
+		{"1.2.3-01", false},
+		{"1.2.3-", false},
+		{"1.2.3+", false},
+		{"1.2.3-alpha..1", false},
+		{"1.2.3-alpha_beta", false},
+		{"vv1.2.3", false},
+		{"V1.2.3", false},
+		{"", false},
+	}
+
+	for _, c := range tc {
+		t.Run(c.version, func(t *testing.T) {
+			v, err := Parse(c.version)
+
+			if c.valid && err != nil {
+				t.Errorf("expected no error; got %s", err)
+			}
+
+			if !c.valid && err == nil {
+				t.Errorf("expected %s to be invalid", c.version)
+			}
+
+			if c.valid && v.String() != c.version {
+				t.Errorf("expected %s; got %s", c.version, v)
+			}
+		})
+	}
+}
+
+func TestCompare(t *testing.T) {
+	// in increasing order of precedence as listed in the specification
+	versions := []string{
+		"1.0.0-alpha",
+		"1.0.0-alpha.1",
+		"1.0.0-alpha.beta",
+		"1.0.0-beta",
+		"1.0.0-beta.2",
+		"1.0.0-beta.11",
+		"1.0.0-rc.1",
+		"1.0.0",
+		"1.0.1",
+		"1.1.0",
+		"1.10.0",
+		"2.0.0",
+	}
+
+	for i := range versions {
+		for j := range versions {
+			a, b := MustParse(versions[i]), MustParse(versions[j])
+
+			expected := 0
+			if i < j {
+				expected = -1
+			} else if i > j {
+				expected = 1
+			}
+
+			if actual := a.Compare(b); actual != expected {
+				t.Errorf("expected %s compared to %s to be %d; got %d", a, b, expected, actual)
+			}
+		}
+	}
+
+	if MustParse("v1.0.0+a").Compare(MustParse("1.0.0+b")) != 0 {
+		t.Error("expected prefix and build metadata to be ignored")
+	}
+}
+
+func TestSort(t *testing.T) {
+	versions := []Version{
+		MustParse("v1.10.0"),
+		MustParse("v1.2.0"),
+		MustParse("v1.2.0-rc.1"),
+		MustParse("v0.9.0"),
+	}
+
+	Sort(versions)
+
+	expected := []string{"v0.9.0", "v1.2.0-rc.1", "v1.2.0", "v1.10.0"}
+	for i := range expected {
+		if versions[i].String() != expected[i] {
+			t.Errorf("expected %s at %d; got %s", expected[i], i, versions[i])
+		}
+	}
+}
+
+func TestBump(t *testing.T) {
+	tc := []struct {
+		version  string
+		change   Type
+		expected string
+	}{
+		{"v1.2.3", Patch, "v1.2.4"},
+		{"v1.2.3", Minor, "v1.3.0"},
+		{"v1.2.3", Major, "v2.0.0"},
+		{"v0.1.0", Major, "v1.0.0"},
+		{"v1.2.3", Unknown, "v2.0.0"},
+		{"1.2.3+build", Patch, "1.2.4"},
+		{"v2.0.0-rc.1", Patch, "v2.0.0"},
+		{"v2.0.0-rc.1", Minor, "v2.0.0"},
+		{"v2.0.0-rc.1", Major, "v2.0.0"},
+		{"v2.1.0-rc.1", Major, "v3.0.0"},
+		{"v2.1.0-rc.1", Minor, "v2.1.0"},
+		{"v2.1.1-rc.1", Minor, "v2.2.0"},
+	}
+
+	for _, c := range tc {
+		t.Run(c.version+" "+c.change.String(), func(t *testing.T) {
+			if actual := MustParse(c.version).Bump(c.change); actual.String() != c.expected {
+				t.Errorf("expected %s; got %s", c.expected, actual)
+			}
+		})
+	}
+}